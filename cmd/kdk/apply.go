@@ -0,0 +1,83 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile       string
+	applyConfigOnly bool
+	applyPull       bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Converge the KDK container to its config",
+	Long: `Make the KDK container match ~/.kdk/<name>/config.yaml: create it if missing, pull the image if
+missing, recreate it when the image or config changed since it was created, and start it if
+stopped.  Named volumes are kept.  Nothing is done when the KDK already matches its config.
+
+With -f, first write a complete KDK config file to ~/.kdk/<name>/config.yaml.  Use "-f -" to read
+from stdin, and --config-only to write the config without converging the container.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if applyFile != "" {
+			var in io.Reader = os.Stdin
+			if applyFile != "-" {
+				f, err := os.Open(applyFile)
+				if err != nil {
+					log.WithField("error", err).Fatalf("Failed to open config file %s", applyFile)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			if err := CurrentKdkEnvConfig.ApplyConfig(in); err != nil {
+				log.WithField("error", err).Fatal("Failed to apply KDK config")
+			}
+			log.Infof("KDK config written to %s", CurrentKdkEnvConfig.ConfigPath())
+		} else if applyConfigOnly {
+			log.Fatal("--config-only requires a config file specified with -f")
+		}
+		if applyConfigOnly {
+			return
+		}
+
+		actions, err := CurrentKdkEnvConfig.Apply(applyPull)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to converge KDK container")
+		}
+		if len(actions) == 0 {
+			log.Info("KDK is up to date with its config")
+		}
+		for _, action := range actions {
+			log.Infof("KDK %s", action)
+		}
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyFile, "filename", "f", "", "KDK config file to apply (- for stdin)")
+	applyCmd.Flags().BoolVar(&applyConfigOnly, "config-only", false, "Write the config file without converging the KDK container")
+	applyCmd.Flags().BoolVar(&applyPull, "pull", false, "Re-pull the configured image tag, recreating the container if it changed")
+
+	rootCmd.AddCommand(applyCmd)
+}
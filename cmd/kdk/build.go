@@ -0,0 +1,39 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build",
+	Short: "Build a custom KDK image",
+	Long: `Build the KDK image from the configured build context (kdk init --build-context), tagged as the
+configured image repository and tag.  Build args set with --build-arg are passed to the build, and
+are ignored by docker unless the Dockerfile declares them with ARG.  Run kdk recreate, or
+kdk apply, to use the new image.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.BuildImage(); err != nil {
+			log.WithField("error", err).Fatal("Failed to build KDK image")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+}
@@ -0,0 +1,58 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the effective KDK configuration",
+	Long:  `Print the effective KDK configuration as YAML, as it is used to create the KDK container: config.yaml plus the settings kdk adds at create time`,
+	Run: func(cmd *cobra.Command, args []string) {
+		y, err := CurrentKdkEnvConfig.EffectiveConfigYAML()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to build effective KDK configuration")
+		}
+		fmt.Print(string(y))
+	},
+}
+
+var configUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Backfill new KDK config defaults",
+	Long:  `Backfill AppConfig fields added by newer kdk releases with their defaults, and rewrite config.yaml.  Fields present in config.yaml are kept`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		backfilled, err := CurrentKdkEnvConfig.UpgradeConfig(defaultAppConfig)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to upgrade KDK config")
+		}
+		if len(backfilled) == 0 {
+			log.Info("KDK config is up to date")
+			return
+		}
+		log.Infof("Upgraded KDK config, backfilled %d fields", len(backfilled))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configUpgradeCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var describeJSON bool
+
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Summarize the KDK environment",
+	Long: `Summarize the KDK environment on one screen: name, image, ports, state, mounts,
+the fingerprint of the ssh public key which the KDK authorizes, and the ssh command`,
+	Run: func(cmd *cobra.Command, args []string) {
+		description, err := CurrentKdkEnvConfig.Description()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to describe KDK")
+		}
+		if !describeJSON {
+			fmt.Print(description)
+			return
+		}
+		out, err := json.MarshalIndent(description, "", "  ")
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to marshal KDK description")
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	describeCmd.Flags().BoolVar(&describeJSON, "json", false, "Print the description as JSON")
+	rootCmd.AddCommand(describeCmd)
+}
@@ -0,0 +1,44 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <name> <name>",
+	Short: "Diff the configs of two KDK environments",
+	Long:  `Diff the config.yaml of two KDK environments, grouped by AppConfig, mounts, env, and ports`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		diff, err := CurrentKdkEnvConfig.DiffConfigs(args[0], args[1])
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to diff KDK environments")
+		}
+		if diff == "" {
+			log.Infof("KDK environments [%s] and [%s] are equivalent", args[0], args[1])
+			return
+		}
+		fmt.Print(diff)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
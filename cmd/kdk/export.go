@@ -0,0 +1,67 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/cisco-sso/kdk/pkg/kdk"
+	"github.com/docker/go-units"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput            string
+	exportIncludePrivateKey bool
+	exportIncludeVolumes    bool
+	exportGzip              bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export KDK environment to a tarball",
+	Long:  `Export KDK environment config and keypair, and optionally named volume contents, to a (gzip compressed) tarball for backup or migration.  The tarball is created readable only by you`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportOutput == "" {
+			exportOutput = CurrentKdkEnvConfig.ConfigFile.AppConfig.Name + ".tar"
+			if exportGzip {
+				exportOutput += ".gz"
+			}
+		}
+		if exportIncludePrivateKey {
+			log.Warn("Including KDK private key in export.  Keep the tarball secure.")
+		}
+		size, err := CurrentKdkEnvConfig.ExportEnv(CurrentKdkEnvConfig.ConfigFile.AppConfig.Name, exportOutput, kdk.ExportOptions{
+			IncludePrivateKey: exportIncludePrivateKey,
+			IncludeVolumes:    exportIncludeVolumes,
+			Compress:          exportGzip,
+		})
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to export KDK environment")
+		}
+		log.Infof("KDK environment exported to %s (%s)", exportOutput, units.HumanSize(float64(size)))
+		if exportIncludePrivateKey {
+			log.Warnf("%s contains the KDK ssh private key, and is readable only by you", exportOutput)
+		}
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Tarball path (default \"<name>.tar.gz\", or \"<name>.tar\" with --gzip=false)")
+	exportCmd.Flags().BoolVarP(&exportIncludePrivateKey, "include-private-key", "", false, "Include the KDK ssh private key")
+	exportCmd.Flags().BoolVarP(&exportIncludeVolumes, "include-volumes", "", false, "Include backups of the named volumes declared in the KDK config, e.g. cache volumes.  Volume data is excluded by default")
+	exportCmd.Flags().BoolVarP(&exportGzip, "gzip", "z", true, "Gzip compress the tarball")
+
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,43 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var exportK8sAnnotations map[string]string
+
+var exportK8sCmd = &cobra.Command{
+	Use:   "export-k8s",
+	Short: "Print a Kubernetes manifest of the KDK",
+	Long:  `Print a Kubernetes Pod running the KDK container, a Service for its ssh port, and a Secret holding the KDK public key, translated from the KDK config.  Named volumes become PersistentVolumeClaims, which must exist`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := CurrentKdkEnvConfig.ExportK8s(exportK8sAnnotations)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to export Kubernetes manifest")
+		}
+		fmt.Print(string(manifest))
+	},
+}
+
+func init() {
+	exportK8sCmd.Flags().StringToStringVarP(&exportK8sAnnotations, "annotation", "a", nil, "Annotation of the Pod, Service, and Secret as key=value (repeatable)")
+	rootCmd.AddCommand(exportK8sCmd)
+}
@@ -0,0 +1,38 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <tarball>",
+	Short: "Import KDK environment from a tarball",
+	Long:  `Import KDK environment config and keypair from a tarball created by "kdk export"`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, err := CurrentKdkEnvConfig.ImportEnv(args[0])
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to import KDK environment")
+		}
+		log.Infof("KDK environment [%s] imported.  Connect with `kdk ssh --name %s`", name, name)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
@@ -15,31 +15,161 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/cisco-sso/kdk/pkg/kdk"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initProxy       kdk.ProxyConfig
+	initRemoteSync  bool
+	initSync        kdk.RemoteSyncConfig
+	initLabels      []string
+	initSshAgent    bool
+	initSshAgentKey string
+	initBootstrap   bool
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize KDK",
 	Long:  `Initialize KDK: Create/recreate KDK configuration and pull latest image`,
 	Run: func(cmd *cobra.Command, args []string) {
-		CurrentKdkEnvConfig.CreateKdkConfig()
-		CurrentKdkEnvConfig.CreateKdkSshKeyPair()
+		if err := applyTemplate(cmd); err != nil {
+			log.WithField("error", err).Fatal("Failed to apply KDK template")
+		}
+		labels, err := kdk.ParseLabels(initLabels)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to parse KDK labels")
+		}
+		CurrentKdkEnvConfig.ConfigFile.AppConfig.Labels = labels
+		if initProxy != (kdk.ProxyConfig{}) {
+			CurrentKdkEnvConfig.ConfigFile.AppConfig.Proxy = &initProxy
+		}
+		if initSshAgent {
+			CurrentKdkEnvConfig.ConfigFile.AppConfig.KeyProvider = "agent"
+		}
+		// A per-platform tag map replaces the default image tag, unless one is given explicitly
+		if len(CurrentKdkEnvConfig.ConfigFile.AppConfig.ImageTags) > 0 && !cmd.Flags().Changed("image-tag") {
+			CurrentKdkEnvConfig.ConfigFile.AppConfig.ImageTag = ""
+		}
+		CurrentKdkEnvConfig.ConfigFile.AppConfig.Bootstrap = &initBootstrap
+		if initRemoteSync {
+			CurrentKdkEnvConfig.ConfigFile.AppConfig.RemoteSync = &initSync
+		}
+		if err := CurrentKdkEnvConfig.CreateKdkConfig(); err != nil {
+			log.WithField("error", err).Fatal("Failed to create KDK config")
+		}
+		if initSshAgent {
+			if err := CurrentKdkEnvConfig.CreateKdkSshKeyFromAgent(initSshAgentKey); err != nil {
+				log.WithField("error", err).Fatal("Failed to use ssh-agent key")
+			}
+		} else if err := CurrentKdkEnvConfig.EnsureKey(); err != nil {
+			log.WithField("error", err).Fatal("Failed to create KDK ssh key")
+		}
+		if err := CurrentKdkEnvConfig.SetCurrent(CurrentKdkEnvConfig.ConfigFile.AppConfig.Name); err != nil {
+			log.WithField("error", err).Warn("Failed to set the current KDK environment")
+		}
 		log.Infof("KDK config written to %s. Modify this file to suit your needs.", CurrentKdkEnvConfig.ConfigPath())
 	},
 }
 
+// Set the init flags of the --template which were not set explicitly
+func applyTemplate(cmd *cobra.Command) error {
+	name := CurrentKdkEnvConfig.ConfigFile.AppConfig.Template
+	if name == "" {
+		return nil
+	}
+	template, err := CurrentKdkEnvConfig.Template(name)
+	if err != nil {
+		return err
+	}
+	for flag, value := range template.Flags {
+		f := cmd.Flags().Lookup(flag)
+		if f == nil {
+			return fmt.Errorf("template [%s] sets unknown init flag [%s]", name, flag)
+		}
+		if f.Changed {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			return fmt.Errorf("template [%s]: %v", name, err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Name, "name", "n", "kdk", "KDK Name")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Template, "template", "", "", "Seed the config from a template, listed by kdk templates.  Explicit flags take precedence")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ContainerName, "container-name", "", "", "KDK docker container name (default KDK name)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Hostname, "hostname", "", "", "KDK container hostname (default KDK name)")
+	initCmd.Flags().StringSliceVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.DnsSearch, "dns-search", nil, "DNS search domains of the KDK container (repeatable, e.g. corp.example.com)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Domainname, "domainname", "", "", "KDK container domain name, for an FQDN of <hostname>.<domainname> (e.g. dev.internal)")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Port, "port", "p", kdk.Port, "KDK Port")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.HostIP, "host-ip", "", "127.0.0.1", "KDK Port host bind address (0.0.0.0 publishes on all interfaces)")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.SocketOnly, "socket-only", "", false, "Publish no ssh port.  Reach the KDK sshd through kdk ssh-proxy or kdk ssh-socket")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ImageRepository, "image-repository", "r", "ciscosso/kdk", "KDK Image Repository")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ImageTag, "image-tag", "t", kdk.Version, "KDK Image Tag")
+	initCmd.Flags().StringToStringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ImageTags, "image-tags", "", nil, "KDK Image Tag per platform as arch=tag or os/arch=tag, with default=tag as the fallback (e.g. amd64=debian-latest,arm64=debian-arm64)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.BuildContext, "build-context", "", "", "Directory with a Dockerfile that kdk build builds the KDK image from")
+	initCmd.Flags().StringToStringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.BuildArgs, "build-arg", "", nil, "KDK image build arg as key=value, ignored unless the Dockerfile declares the ARG")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Platform, "platform", "", "", "KDK image platform as os/arch[/variant] (e.g. linux/amd64)")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.DotfilesRepo, "dotfiles-repo", "", "https://github.com/cisco-sso/yadm-dotfiles.git", "KDK Dotfiles Repo")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Shell, "shell", "s", "/bin/bash", "KDK shell")
 	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.SocksPort, "socks-port", "D", "", "KDK SOCKS Port")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ShmSize, "shm-size", "", "", "KDK /dev/shm size (e.g. 1g)")
+	initCmd.Flags().StringVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.StopSignal, "stop-signal", "", "Signal sent to stop the KDK container (default the image's, e.g. SIGRTMIN+3 for systemd)")
+	initCmd.Flags().IntVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.StopTimeout, "stop-timeout", kdk.DefaultStopTimeout, "Seconds the KDK container is given to stop, including on host shutdown, before it is killed")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Memory, "memory", "", "", "KDK container memory limit (e.g. 8g)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.MemorySwap, "memory-swap", "", "", "KDK container memory plus swap limit (e.g. 12g), or -1 for unlimited swap")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.CpusetCpus, "cpuset-cpus", "", "", "Pin the KDK container to these CPUs (e.g. 0-3 or 0,2)")
+	initCmd.Flags().Int64VarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.CpuShares, "cpu-shares", "", 0, "KDK container relative CPU weight (docker default 1024)")
+	initCmd.Flags().IntVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.OomScoreAdj, "oom-score-adj", "", 0, "KDK container OOM score adjustment (-1000 to 1000; lower is killed last)")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.OomKillDisable, "oom-kill-disable", "", false, "Disable the OOM killer for the KDK container")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.AutoRemove, "auto-remove", "", false, "Automatically remove the KDK container when it exits")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.CgroupParent, "cgroup-parent", "", "", "KDK container cgroup parent")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Network, "network", "", "", "User-defined docker network for the KDK container")
+	initCmd.Flags().StringArrayVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.NetworkAliases, "network-alias", "", nil, "KDK container alias on --network (repeatable)")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Privileged, "privileged", "", true, "Run the KDK container privileged (required for docker in docker)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.UsernsMode, "userns-mode", "", "", "KDK container user namespace mode: host opts out of the docker daemon's --userns-remap")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.MatchHostUid, "match-host-uid", "", false, "Create the KDK user with the host uid/gid so files on host mounts are owned by the host user")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.SyncTimezone, "sync-timezone", "", false, "Mount the host /etc/localtime read-only and set TZ")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Locale, "locale", "", "", "KDK container LANG (e.g. en_US.UTF-8)")
+	initCmd.Flags().StringVarP(&initProxy.HTTP, "http-proxy", "", "", "KDK container HTTP_PROXY (e.g. http://proxy.example.com:8080)")
+	initCmd.Flags().StringVarP(&initProxy.HTTPS, "https-proxy", "", "", "KDK container HTTPS_PROXY")
+	initCmd.Flags().StringVarP(&initProxy.NoProxy, "no-proxy", "", "", "KDK container NO_PROXY, comma separated")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.LogDriver, "log-driver", "", "json-file", "KDK container log driver")
+	initCmd.Flags().StringToStringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.LogOptions, "log-opt", "", nil, "KDK container log driver option as key=value (default max-size=10m,max-file=3 for json-file and local)")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.ChownMounts, "chown-mounts", "", false, "Chown writable mount targets under the KDK home (not their contents) to the KDK user at start")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.PersistHome, "persist-home", "", "", "Persist the KDK user home in this host directory or named docker volume, across recreates")
+	initCmd.Flags().StringSliceVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.CacheVolumes, "cache-volumes", "", nil, "Persist dependency caches in named volumes: go, npm, yarn, pip, maven, gradle, cargo (comma separated)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Relabel, "relabel", "", "", "SELinux relabel additional host directory mounts: shared (:z) or private (:Z).  Applied only on SELinux hosts")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.MountCommonDotfiles, "mount-common-dotfiles", "", false, "Mount host ~/.gitconfig, ~/.aws, and ~/.kube read-only when present")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.EnvFile, "env-file", "", "", "Host .env file of KEY=VALUE lines merged into the KDK container environment")
+	initCmd.Flags().StringVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.MountProfile, "mount-profile", "", "Consistency of all bind mounts on macOS: fast (delegated) or safe (consistent).  Ignored elsewhere")
+	initCmd.Flags().BoolVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.MountHostCACerts, "mount-host-ca-certs", false, "Mount the host CA bundle read-only and trust it in the KDK, e.g. behind a TLS intercepting proxy")
+	initCmd.Flags().StringVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.HostCACertsPath, "host-ca-certs-path", "", "Host CA bundle for --mount-host-ca-certs (default detected per OS)")
+	initCmd.Flags().BoolVar(&initRemoteSync, "remote-sync", false, "Sync mounted host directories into volumes with kdk sync, for remote docker daemons which can't bind mount them")
+	initCmd.Flags().StringSliceVar(&initSync.Include, "remote-sync-include", nil, "Sync only files matching these patterns (repeatable)")
+	initCmd.Flags().StringSliceVar(&initSync.Exclude, "remote-sync-exclude", nil, "Don't sync files or directories matching these patterns, e.g. .git (repeatable)")
+	initCmd.Flags().StringVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Kubeconfig, "kubeconfig", "", "Host kubeconfig mounted read-only at ~/.kube/config in the KDK (e.g. ~/.kube/config)")
+	initCmd.Flags().StringSliceVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.KubeContexts, "kube-context", nil, "Mount only these contexts of --kubeconfig (repeatable)")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.SshdConfig, "sshd-config", "", "", "Host sshd config file mounted as an sshd_config.d drop-in (e.g. ciphers, MACs)")
+	initCmd.Flags().StringArrayVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.KeybasePaths, "keybase-path", "", nil, "Mount only this keybase path, e.g. team/<name> (repeatable)")
+	initCmd.Flags().StringArrayVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.AuthorizedKeySources, "authorized-key-source", "", nil, "Also authorize the ssh keys of github:<user>, gitlab:<user>, a URL, or a file (repeatable)")
+	initCmd.Flags().BoolVarP(&initSshAgent, "ssh-agent", "", false, "Use a key held by ssh-agent (e.g. a hardware key) instead of generating a KDK keypair")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.KeyProvider, "key-provider", "", "", "Source the KDK ssh keypair from this registered KeyProvider (default file)")
+	initCmd.Flags().StringVarP(&initSshAgentKey, "ssh-agent-key", "", "", "Select the ssh-agent key by comment or fingerprint substring")
+	initCmd.Flags().StringArrayVarP(&initLabels, "label", "l", nil, "KDK container label as key=value (repeatable)")
+	initCmd.Flags().BoolVarP(&initBootstrap, "bootstrap", "", true, "Run the KDK bootstrap.  Set false for minimal images with only sshd: the public key is copied into authorized_keys at start")
+	initCmd.Flags().StringVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.IdleTimeout, "idle-timeout", "", "", "Stop the KDK container after this long without ssh sessions (e.g. 2h).  Requires the KDK bootstrap")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Tty, "tty", "", true, "Allocate a tty for the KDK container.  Set false for automation that parses the container logs")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Init, "init", "", false, "Run an init process (tini) as pid 1 of the KDK container to forward signals and reap zombies.  Ignored for images whose entrypoint is an init, e.g. systemd")
+	initCmd.Flags().BoolVarP(&CurrentKdkEnvConfig.ConfigFile.AppConfig.KeepAlive, "keep-alive", "", false, "Hold the KDK container open for images without a long-running process")
 
 	rootCmd.AddCommand(initCmd)
 }
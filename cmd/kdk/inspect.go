@@ -0,0 +1,39 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print the docker inspect of the KDK container",
+	Long:  `Print the raw docker inspect of the KDK container as JSON, e.g. for bug reports`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := CurrentKdkEnvConfig.InspectJSON()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to inspect KDK container")
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+}
@@ -15,19 +15,25 @@
 package cmd
 
 import (
-	"io/ioutil"
+	"errors"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/cisco-sso/kdk/pkg/kdk"
-	"github.com/ghodss/yaml"
+	"github.com/cisco-sso/kdk/pkg/prompt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var (
 	CurrentKdkEnvConfig = kdk.KdkEnvConfig{}
 	debug               = false
+
+	// AppConfig defaults, as registered by the `kdk init` flags, before config.yaml is loaded over them
+	defaultAppConfig kdk.AppConfig
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -47,16 +53,46 @@ A full kubernetes development environment in a container`,
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.WithFields(log.Fields{"err": err}).Fatal("Failed to execute RootCmd.")
+		log.WithFields(log.Fields{"err": err}).Error("Failed to execute RootCmd.")
+		os.Exit(kdk.ExitUsage)
 	}
 }
 
+// Commands fail with log.Fatal.  Exit with the kdk.ExitCode of the logged error instead of 1.
+type exitCodeHook struct {
+	code int
+}
+
+func (h *exitCodeHook) Levels() []log.Level {
+	return []log.Level{log.FatalLevel}
+}
+
+func (h *exitCodeHook) Fire(entry *log.Entry) error {
+	h.code = kdk.ExitError
+	for _, key := range []string{"error", "err"} {
+		if err, ok := entry.Data[key].(error); ok {
+			h.code = kdk.ExitCode(err)
+		}
+	}
+	return nil
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
-	CurrentKdkEnvConfig.Init()
+
+	hook := &exitCodeHook{code: kdk.ExitError}
+	log.AddHook(hook)
+	log.StandardLogger().ExitFunc = func(int) { os.Exit(hook.code) }
 
 	rootCmd.PersistentFlags().StringVar(&CurrentKdkEnvConfig.ConfigFile.AppConfig.Name, "name", "kdk", "KDK name")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "Debug Mode")
+	rootCmd.PersistentFlags().StringVar(&CurrentKdkEnvConfig.DockerContext, "context", "", "Docker context name (default DOCKER_CONTEXT, or the docker cli current context)")
+	rootCmd.PersistentFlags().BoolVar(&CurrentKdkEnvConfig.ForceLocked, "force-locked", false, "Allow overwriting a locked KDK config")
+	rootCmd.PersistentFlags().IntVar(&kdk.DockerMaxRetries, "docker-max-retries", kdk.DockerMaxRetries, "Maximum retries of transient docker API errors")
+	kdk.DirMode = kdk.EnvFileMode("KDK_DIR_MODE", kdk.DirMode)
+	kdk.FileMode = kdk.EnvFileMode("KDK_FILE_MODE", kdk.FileMode)
+	rootCmd.PersistentFlags().DurationVar(&CurrentKdkEnvConfig.DockerTimeout, "docker-timeout", kdk.EnvDuration("KDK_DOCKER_TIMEOUT", 0), "Docker API request timeout, 0 for none (default KDK_DOCKER_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&CurrentKdkEnvConfig.DockerKeepAlive, "docker-keepalive", kdk.EnvDuration("KDK_DOCKER_KEEPALIVE", 30*time.Second), "TCP keepalive period of tcp docker daemon connections (default KDK_DOCKER_KEEPALIVE)")
 }
 
 func initConfig() {
@@ -65,14 +101,30 @@ func initConfig() {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	// The docker client is created after flag parsing, so that --context applies
+	if err := CurrentKdkEnvConfig.Init(); err != nil {
+		log.WithField("error", err).Fatal("Failed to create docker client.  Ensure that docker is running.")
+	}
+
 	if _, err := os.Stat(CurrentKdkEnvConfig.ConfigRootDir()); os.IsNotExist(err) {
 		err = os.Mkdir(CurrentKdkEnvConfig.ConfigRootDir(), 0700)
 		if err != nil {
-			log.WithField("err", err).Fatal("Unable to create Config Directory")
+			log.WithField("error", err).Fatal("Unable to create Config Directory")
 		}
 	}
 
-	viper.SetConfigFile(CurrentKdkEnvConfig.ConfigPath())
+	// Commands run without --name target the current KDK environment.  `kdk init` creates "kdk" by default.
+	if cmd, _, err := rootCmd.Find(os.Args[1:]); err == nil && cmd != initCmd && !cmd.Flags().Changed("name") {
+		if name, err := CurrentKdkEnvConfig.GetCurrent(); err != nil {
+			log.WithField("error", err).Warn("Failed to determine the current KDK environment")
+		} else if name != "" {
+			CurrentKdkEnvConfig.ConfigFile.AppConfig.Name = name
+		} else if targetsEnv(cmd) {
+			selectEnv()
+		}
+	}
+
+	viper.SetConfigFile(CurrentKdkEnvConfig.ConfigSource())
 
 	viper.SetEnvPrefix("kdk")
 	viper.AutomaticEnv()
@@ -84,19 +136,39 @@ func initConfig() {
 	if viper.GetBool("json") {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
-	if _, err := os.Stat(CurrentKdkEnvConfig.ConfigPath()); err == nil {
-		// read the config.yaml file
-		data, err := ioutil.ReadFile(CurrentKdkEnvConfig.ConfigPath())
-		if err != nil {
-			log.WithField("err", err).Fatalf("Failed to read configFile %v", CurrentKdkEnvConfig.ConfigPath())
+	// read the config.yaml file
+	defaultAppConfig = CurrentKdkEnvConfig.ConfigFile.AppConfig
+	if err := CurrentKdkEnvConfig.LoadKdkConfig(); err != nil {
+		if !errors.Is(err, kdk.ErrConfigNotFound) {
+			log.WithField("error", err).Fatal("Corrupted or deprecated kdk config file format.  Please rebuild config file with `kdk init`")
 		}
+	} else {
+		kdk.WarnIfUpdateAvailable(&CurrentKdkEnvConfig)
+	}
+}
 
-		err = yaml.Unmarshal(data, &CurrentKdkEnvConfig.ConfigFile)
-		if err != nil {
-			log.WithField("err", err).Error("Corrupted or deprecated kdk config file format")
-			log.Fatal("Please rebuild config file with `kdk init`")
-		} else {
-			kdk.WarnIfUpdateAvailable(&CurrentKdkEnvConfig)
-		}
+// Whether cmd operates on a single KDK environment, rather than on all of them or none
+func targetsEnv(cmd *cobra.Command) bool {
+	switch cmd {
+	case rootCmd, initCmd, listCmd, useCmd, diffCmd, importCmd, reapCmd, schemaCmd, selfTestCmd, templatesCmd, versionCmd:
+		return false
+	}
+	return cmd.Name() != "help"
+}
+
+// Without a current environment, pick one of several interactively.  Non-interactive runs must name one.
+func selectEnv() {
+	names, err := CurrentKdkEnvConfig.ListKdkConfigs()
+	if err != nil || len(names) < 2 {
+		return
+	}
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		log.Fatalf("Multiple KDK environments exist (%s).  Pass --name, or set one with `kdk use <name>`", strings.Join(names, ", "))
+	}
+	selector := prompt.Select{Text: "Select a KDK environment (number or name): ", Options: names}
+	name, err := selector.Run()
+	if err != nil {
+		log.WithField("error", err).Fatal("No KDK environment selected")
 	}
+	CurrentKdkEnvConfig.ConfigFile.AppConfig.Name = name
 }
@@ -0,0 +1,64 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage the KDK ssh key",
+	Long:  `Manage the KDK ssh key`,
+}
+
+var keyRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refresh the KDK public key in the running container",
+	Long:  `Replace authorized_keys in the running KDK container with the current KDK public key, e.g. after regenerating the keypair`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.RefreshAuthorizedKeys(); err != nil {
+			log.WithField("error", err).Fatal("Failed to refresh KDK authorized_keys")
+		}
+	},
+}
+
+var keyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the KDK ssh public key and its fingerprint",
+	Long:  `Print the SHA256 fingerprint and the full KDK ssh public key, e.g. to register the key with other hosts or services`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fingerprint, err := CurrentKdkEnvConfig.Fingerprint()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to read KDK ssh public key")
+		}
+		publicKey, err := CurrentKdkEnvConfig.PublicKey()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to read KDK ssh public key")
+		}
+		fmt.Println(fingerprint)
+		fmt.Println(publicKey)
+	},
+}
+
+func init() {
+	keyCmd.AddCommand(keyRefreshCmd)
+	keyCmd.AddCommand(keyShowCmd)
+	rootCmd.AddCommand(keyCmd)
+}
@@ -0,0 +1,49 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List KDK environments",
+	Long:  `List KDK environments, marking the current environment with *`,
+	Run: func(cmd *cobra.Command, args []string) {
+		names, err := CurrentKdkEnvConfig.ListKdkConfigs()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to list KDK environments")
+		}
+		current, err := CurrentKdkEnvConfig.GetCurrent()
+		if err != nil {
+			log.WithField("error", err).Warn("Failed to determine the current KDK environment")
+		}
+		for _, name := range names {
+			if name == current {
+				fmt.Printf("* %s\n", name)
+			} else {
+				fmt.Printf("  %s\n", name)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
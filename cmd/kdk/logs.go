@@ -0,0 +1,48 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var logsOptions kdk.LogsOptions
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Print the KDK container logs",
+	Long: `Print the KDK container logs, optionally only lines matching --grep, e.g.
+
+  kdk logs --grep sshd
+  kdk logs --bootstrap --grep authorized_keys`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.Logs(os.Stdout, logsOptions); err != nil {
+			log.WithField("error", err).Fatal("Failed to get KDK logs")
+		}
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsOptions.Follow, "follow", "f", false, "Follow the log output")
+	logsCmd.Flags().StringVarP(&logsOptions.Tail, "tail", "", "all", "Number of lines to show from the end of the log")
+	logsCmd.Flags().StringVarP(&logsOptions.Filter, "grep", "g", "", "Only show lines matching this regular expression")
+	logsCmd.Flags().BoolVarP(&logsOptions.Bootstrap, "bootstrap", "", false, "Show the KDK bootstrap log (dotfiles and user provisioning) instead")
+	rootCmd.AddCommand(logsCmd)
+}
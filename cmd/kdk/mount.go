@@ -0,0 +1,84 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountReadOnly bool
+	mountRelabel  string
+)
+
+var mountCmd = &cobra.Command{
+	Use:   "mount",
+	Short: "Manage KDK host directory mounts",
+	Long:  `Manage KDK host directory mounts`,
+}
+
+var mountAddCmd = &cobra.Command{
+	Use:   "add <source> <target>",
+	Short: "Add a host directory mount to the KDK config",
+	Long:  `Add a host directory mount to the KDK config`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		source, err := filepath.Abs(args[0])
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to resolve mount source")
+		}
+		recreate, err := CurrentKdkEnvConfig.AddMount(mount.Mount{Type: mount.TypeBind, Source: source, Target: args[1],
+			ReadOnly: mountReadOnly, Consistency: mount.ConsistencyCached}, mountRelabel)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to add KDK mount")
+		}
+		log.Infof("Added mount %s:%s to KDK config", source, args[1])
+		warnRecreate(recreate)
+	},
+}
+
+var mountRemoveCmd = &cobra.Command{
+	Use:   "remove <target>",
+	Short: "Remove a mount from the KDK config",
+	Long:  `Remove a mount from the KDK config`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		recreate, err := CurrentKdkEnvConfig.RemoveMount(args[0])
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to remove KDK mount")
+		}
+		log.Infof("Removed mount %s from KDK config", args[0])
+		warnRecreate(recreate)
+	},
+}
+
+func warnRecreate(recreate bool) {
+	if recreate {
+		log.Warn("The KDK container must be recreated for this change to take effect: `kdk destroy && kdk up`")
+	}
+}
+
+func init() {
+	mountAddCmd.Flags().BoolVarP(&mountReadOnly, "read-only", "", false, "Mount read-only")
+	mountAddCmd.Flags().StringVarP(&mountRelabel, "relabel", "", "", "SELinux relabel the mount source: shared (:z) or private (:Z).  Applied only on SELinux hosts")
+
+	mountCmd.AddCommand(mountAddCmd)
+	mountCmd.AddCommand(mountRemoveCmd)
+	rootCmd.AddCommand(mountCmd)
+}
@@ -0,0 +1,42 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <container-port>",
+	Short: "Open a browser to a published KDK port",
+	Long:  `Open the default browser to the host port published for a KDK container port, e.g. a web UI`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		port, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.WithField("error", err).Fatal("Container port must be an integer")
+		}
+		if err := CurrentKdkEnvConfig.OpenBrowser(port); err != nil {
+			log.WithField("error", err).Fatal("Failed to open browser")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
@@ -16,6 +16,8 @@ package cmd
 
 import (
 	"github.com/cisco-sso/kdk/pkg/kdk"
+
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -24,7 +26,9 @@ var provisionCmd = &cobra.Command{
 	Short: "Provision KDK user",
 	Long:  `Provision KDK user`,
 	Run: func(cmd *cobra.Command, args []string) {
-		kdk.Provision(CurrentKdkEnvConfig)
+		if err := kdk.Provision(CurrentKdkEnvConfig); err != nil {
+			log.WithField("error", err).Fatal("Failed to provision KDK user")
+		}
 	},
 }
 
@@ -16,18 +16,25 @@ package cmd
 
 import (
 	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var pruneDryRun bool
+
 var pruneCmd = &cobra.Command{
 	Use:   "prune",
 	Short: "Prune unused KDK container images",
-	Long:  `Prune unused KDK container images`,
+	Long: `Prune the local images of configured KDK image repositories that no KDK config references and no
+container uses, confirming each.  Images of other repositories are never removed.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		kdk.Prune(CurrentKdkEnvConfig)
+		if err := kdk.Prune(CurrentKdkEnvConfig, pruneDryRun); err != nil {
+			log.WithField("error", err).Fatal("Failed to prune KDK images")
+		}
 	},
 }
 
 func init() {
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "", false, "List the stale KDK images that would be pruned")
 	rootCmd.AddCommand(pruneCmd)
 }
@@ -0,0 +1,64 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reapOlderThan time.Duration
+	reapUser      string
+	reapDryRun    bool
+)
+
+var reapCmd = &cobra.Command{
+	Use:   "reap",
+	Short: "Remove KDK containers by age or user",
+	Long:  `Remove the KDK containers of any environment created more than --older-than ago and/or belonging to --user, e.g. to clean up a shared host`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reapOlderThan == 0 && reapUser == "" {
+			log.Fatal("Refusing to reap every KDK container: specify --older-than and/or --user")
+		}
+		if reapDryRun {
+			containers, err := CurrentKdkEnvConfig.ReapableKdks(reapOlderThan, reapUser)
+			if err != nil {
+				log.WithField("error", err).Fatal("Failed to find KDK containers")
+			}
+			for _, container := range containers {
+				fmt.Println(strings.TrimPrefix(strings.Join(container.Names, ","), "/"))
+			}
+			return
+		}
+		reaped, err := CurrentKdkEnvConfig.ReapKdk(reapOlderThan, reapUser)
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to reap KDK containers")
+		}
+		log.Infof("Reaped %d KDK container(s)", len(reaped))
+	},
+}
+
+func init() {
+	reapCmd.Flags().DurationVarP(&reapOlderThan, "older-than", "", 0, "Reap KDK containers created longer ago than this (e.g. 720h)")
+	reapCmd.Flags().StringVarP(&reapUser, "user", "", "", "Reap KDK containers belonging to this user")
+	reapCmd.Flags().BoolVarP(&reapDryRun, "dry-run", "", false, "List the KDK containers that would be reaped")
+
+	rootCmd.AddCommand(reapCmd)
+}
@@ -0,0 +1,40 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var recreateDiscardVolumes bool
+
+var recreateCmd = &cobra.Command{
+	Use:   "recreate",
+	Short: "Recreate the KDK container from config",
+	Long:  `Remove and recreate the KDK container from config, e.g. after changing the image tag.  Named volumes are kept unless --discard-volumes is set`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.RecreateKdk(!recreateDiscardVolumes); err != nil {
+			log.WithField("error", err).Fatal("Failed to recreate KDK container")
+		}
+		log.Info("KDK recreate complete.")
+	},
+}
+
+func init() {
+	recreateCmd.Flags().BoolVarP(&recreateDiscardVolumes, "discard-volumes", "", false, "Also remove the named volumes declared in the KDK config")
+
+	rootCmd.AddCommand(recreateCmd)
+}
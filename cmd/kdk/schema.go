@@ -0,0 +1,40 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the KDK config file",
+	Long:  `Print the JSON Schema of the KDK config file (~/.kdk/<name>/config.yaml), e.g. for editor autocomplete and validation`,
+	Run: func(cmd *cobra.Command, args []string) {
+		schema, err := kdk.GenerateSchema()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to generate KDK config schema")
+		}
+		fmt.Println(string(schema))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
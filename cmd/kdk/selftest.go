@@ -0,0 +1,36 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var selfTestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Verify the KDK install end to end",
+	Long:  `Verify the KDK install end to end: create a throwaway KDK, ssh to it with the KDK keypair, then destroy it`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.SelfTest(log.StandardLogger()); err != nil {
+			log.WithField("error", err).Fatal("KDK self-test FAILED")
+		}
+		log.Info("KDK self-test PASSED")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selfTestCmd)
+}
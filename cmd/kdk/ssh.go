@@ -16,20 +16,39 @@ package cmd
 
 import (
 	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var sshNative bool
+
 var sshCmd = &cobra.Command{
 	Use:   "ssh",
 	Short: "Connect to running KDK container via ssh",
 	Long:  `Connect to running KDK container via ssh`,
 	Run: func(cmd *cobra.Command, args []string) {
-		kdk.Ssh(CurrentKdkEnvConfig)
+		if !sshNative {
+			kdk.Ssh(CurrentKdkEnvConfig)
+			return
+		}
+
+		if CurrentKdkEnvConfig.SocksPort != "" {
+			log.Warn("SOCKS proxy is not supported by the native ssh client.  Ignoring --socks-port")
+		}
+		if err := CurrentKdkEnvConfig.Start(); err != nil {
+			log.WithField("error", err).Fatal("Failed to start KDK container")
+		}
+		log.Info("Connecting to KDK container")
+		if err := CurrentKdkEnvConfig.Connect(); err != nil {
+			log.WithField("error", err).Fatal("Failed to ssh to KDK container.")
+		}
+		log.Info("KDK session exited")
 	},
 }
 
 func init() {
 	sshCmd.Flags().StringVarP(&CurrentKdkEnvConfig.SocksPort, "socks-port", "D", "", "KDK SOCKS Port")
+	sshCmd.Flags().BoolVarP(&sshNative, "native", "", false, "Use the built-in ssh client instead of the ssh binary")
 
 	rootCmd.AddCommand(sshCmd)
 }
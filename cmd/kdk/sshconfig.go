@@ -0,0 +1,38 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "Write the KDK Host entry to ~/.ssh/config",
+	Long: `Write or regenerate a Host entry for the KDK in ~/.ssh/config, so that plain ssh, scp, and
+editors connect with "ssh <container name>".  kdk keeps the entry's Port current when the KDK
+port changes on start.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.WriteSshConfigEntry(); err != nil {
+			log.WithField("error", err).Fatal("Failed to write ssh config entry")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+}
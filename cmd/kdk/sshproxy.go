@@ -0,0 +1,55 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var sshProxyCmd = &cobra.Command{
+	Use:   "ssh-proxy",
+	Short: "Relay stdio to the KDK sshd",
+	Long: `Relay stdin and stdout to the KDK sshd through the docker API, for use as an ssh ProxyCommand.
+A KDK created with --socket-only publishes no port, so ssh connects with
+"-o ProxyCommand='kdk ssh-proxy --name <name>'".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.SshProxy(os.Stdin, os.Stdout); err != nil {
+			log.WithField("error", err).Fatal("Failed to relay to KDK sshd")
+		}
+	},
+}
+
+var sshSocketCmd = &cobra.Command{
+	Use:   "ssh-socket",
+	Short: "Serve the KDK sshd on a local Unix socket",
+	Long: `Serve the KDK sshd on a Unix socket in the KDK config dir, readable only by the user, for tools
+that connect to a socket rather than run a ProxyCommand, e.g.
+"-o ProxyCommand='nc -U ~/.kdk/<name>/ssh.sock'".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.ServeSshSocket(); err != nil {
+			log.WithField("error", err).Fatal("Failed to serve KDK ssh socket")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshProxyCmd)
+	rootCmd.AddCommand(sshSocketCmd)
+}
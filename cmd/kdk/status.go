@@ -0,0 +1,39 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show KDK container status",
+	Long:  `Show KDK container status, including the host ports published by the running container`,
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := CurrentKdkEnvConfig.Status()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to get KDK status")
+		}
+		fmt.Print(status)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
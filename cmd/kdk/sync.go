@@ -0,0 +1,41 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var syncOnce bool
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync host directories into the KDK",
+	Long: `Sync the mounted host directories of a RemoteSync KDK into their volumes on the docker host,
+then keep watching them for changes.  Use with a remote docker daemon, which can't bind mount
+host directories.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.SyncMounts(!syncOnce); err != nil {
+			log.WithField("error", err).Fatal("Failed to sync host directories to the KDK")
+		}
+	},
+}
+
+func init() {
+	syncCmd.Flags().BoolVar(&syncOnce, "once", false, "Sync once and exit, rather than watching for changes")
+	rootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List KDK templates",
+	Long: `List the templates for "kdk init --template": the built-in templates, and those of
+~/.kdk/templates.yaml, which override built-ins of the same name`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		templates, err := CurrentKdkEnvConfig.Templates()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to load KDK templates")
+		}
+		names, err := CurrentKdkEnvConfig.TemplateNames()
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to load KDK templates")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", name, templates[name].Description)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+}
@@ -0,0 +1,46 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var topNoStream bool
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show live KDK container resource usage",
+	Long:  `Show the cpu, memory, network, and block IO usage of the running KDK container, updated about every second`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := CurrentKdkEnvConfig.Stats(!topNoStream, func(stats kdk.KdkStats) error {
+			fmt.Println(stats)
+			return nil
+		})
+		if err != nil {
+			log.WithField("error", err).Fatal("Failed to get KDK container stats")
+		}
+	},
+}
+
+func init() {
+	topCmd.Flags().BoolVar(&topNoStream, "no-stream", false, "Print a single sample and exit")
+	rootCmd.AddCommand(topCmd)
+}
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"github.com/cisco-sso/kdk/pkg/kdk"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -24,8 +25,18 @@ var upCmd = &cobra.Command{
 	Short: "Start KDK container",
 	Long:  `Start KDK container`,
 	Run: func(cmd *cobra.Command, args []string) {
-		kdk.Up(CurrentKdkEnvConfig)
-		kdk.Provision(CurrentKdkEnvConfig)
+		if err := CurrentKdkEnvConfig.FetchKey(); err != nil {
+			log.WithField("error", err).Fatal("Failed to fetch KDK ssh key")
+		}
+		if err := CurrentKdkEnvConfig.StartSidecars(); err != nil {
+			log.WithField("error", err).Fatal("Failed to start KDK sidecars")
+		}
+		if err := kdk.Up(&CurrentKdkEnvConfig); err != nil {
+			log.WithField("error", err).Fatal("Failed to start KDK container")
+		}
+		if err := CurrentKdkEnvConfig.Prepare(); err != nil {
+			log.WithField("error", err).Fatal("KDK bootstrap did not complete")
+		}
 	},
 }
 
@@ -0,0 +1,37 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var useCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current KDK environment",
+	Long:  `Switch the current KDK environment, which is targeted by commands run without --name`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.SetCurrent(args[0]); err != nil {
+			log.WithField("error", err).Fatal("Failed to switch KDK environment")
+		}
+		log.Infof("Current KDK environment is now [%s]", args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(useCmd)
+}
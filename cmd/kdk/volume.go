@@ -0,0 +1,58 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var volumeCmd = &cobra.Command{
+	Use:   "volume",
+	Short: "Back up and restore KDK named volumes",
+	Long:  `Back up and restore the contents of docker named volumes, e.g. cache volumes or a persisted home`,
+}
+
+var volumeBackupCmd = &cobra.Command{
+	Use:   "backup <volume> <tarball>",
+	Short: "Back up a named volume to a tarball",
+	Long:  `Back up the contents of a docker named volume to a tarball on the host`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.BackupVolume(args[0], args[1]); err != nil {
+			log.WithField("error", err).Fatal("Failed to back up volume")
+		}
+		log.Infof("Volume [%s] backed up to %s", args[0], args[1])
+	},
+}
+
+var volumeRestoreCmd = &cobra.Command{
+	Use:   "restore <volume> <tarball>",
+	Short: "Restore a named volume from a tarball",
+	Long:  `Restore the contents of a docker named volume from a tarball created by "kdk volume backup"`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := CurrentKdkEnvConfig.RestoreVolume(args[0], args[1]); err != nil {
+			log.WithField("error", err).Fatal("Failed to restore volume")
+		}
+		log.Infof("Volume [%s] restored from %s", args[0], args[1])
+	},
+}
+
+func init() {
+	volumeCmd.AddCommand(volumeBackupCmd)
+	volumeCmd.AddCommand(volumeRestoreCmd)
+	rootCmd.AddCommand(volumeCmd)
+}
@@ -0,0 +1,89 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Connect to the local ssh-agent
+func sshAgent() (agent.ExtendedAgent, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("SSH_AUTH_SOCK is not set.  Is ssh-agent running?")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	return agent.NewClient(conn), nil
+}
+
+// Source the KDK public key from a key held by ssh-agent (e.g. a hardware key), rather than
+// generating a keypair.  No private key is written; ssh authenticates via the agent.
+// filter selects the key by a substring of its comment or SHA256 fingerprint, and must match
+// exactly one key.  An empty filter is allowed when the agent holds a single key.
+func (c *KdkEnvConfig) CreateKdkSshKeyFromAgent(filter string) error {
+	if _, err := os.Stat(c.PrivateKeyPath()); err == nil {
+		return fmt.Errorf("KDK ssh private key [%s] exists.  Remove the KDK keypair to use an ssh-agent key", c.PrivateKeyPath())
+	}
+
+	sshAgent, err := sshAgent()
+	if err != nil {
+		return err
+	}
+	keys, err := sshAgent.List()
+	if err != nil {
+		return err
+	}
+
+	var selected []*agent.Key
+	for _, key := range keys {
+		if filter == "" || strings.Contains(key.Comment, filter) || strings.Contains(gossh.FingerprintSHA256(key), filter) {
+			selected = append(selected, key)
+		}
+	}
+	if len(selected) != 1 {
+		var candidates []string
+		for _, key := range selected {
+			candidates = append(candidates, gossh.FingerprintSHA256(key)+" "+key.Comment)
+		}
+		return fmt.Errorf("%d ssh-agent keys match [%s], exactly one is required: %s", len(selected), filter, strings.Join(candidates, ", "))
+	}
+	key := selected[0]
+
+	if err := os.MkdirAll(c.KeypairDir(), 0700); err != nil {
+		return err
+	}
+	publicKey := gossh.MarshalAuthorizedKey(key)
+	if existing, err := ioutil.ReadFile(c.PublicKeyPath()); err == nil && !bytes.Equal(bytes.TrimSpace(existing), bytes.TrimSpace(publicKey)) {
+		log.Warnf("Replacing existing KDK ssh public key [%s]", c.PublicKeyPath())
+	}
+	if err := ioutil.WriteFile(c.PublicKeyPath(), publicKey, 0600); err != nil {
+		return err
+	}
+	log.Infof("Using ssh-agent key %s %s", gossh.FingerprintSHA256(key), key.Comment)
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Staging path for the public key copied into the KDK container
+const authorizedKeyStaging = "/tmp/kdk-authorized_keys"
+
+// Replace authorized_keys in the running KDK container with the current KDK public key and the
+// AuthorizedKeySources, e.g. after regenerating the keypair, without recreating the container
+func (c *KdkEnvConfig) RefreshAuthorizedKeys() error {
+	if running, err := c.IsRunning(); err != nil {
+		return err
+	} else if !running {
+		return wrapError(ErrContainerNotFound, fmt.Errorf("KDK container [%s] is not running", c.ContainerName()))
+	}
+	publicKey, err := c.authorizedKeys()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: path.Base(authorizedKeyStaging), Mode: 0600, Size: int64(len(publicKey))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(publicKey); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := c.DockerClient.CopyToContainer(c.Ctx, c.ContainerName(), path.Dir(authorizedKeyStaging), &buf, types.CopyToContainerOptions{}); err != nil {
+		return wrapDockerError(err)
+	}
+
+	// install as root with the ownership and permissions sshd requires.  The primary group of the
+	//   user may not be named after it (kdk init --match-host-uid).
+	sshDir := path.Join(c.ContainerHome(), ".ssh")
+	script := fmt.Sprintf("group=$(id -gn %[1]s) && install -d -o %[1]s -g \"$group\" -m 0700 %[2]s && install -o %[1]s -g \"$group\" -m 0600 %[3]s %[2]s/authorized_keys; status=$?; rm -f %[3]s; exit $status",
+		c.User(), sshDir, authorizedKeyStaging)
+	out, exitCode, err := c.containerExec("", "sh", "-c", script)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to install authorized_keys (exit %d): %s", exitCode, out)
+	}
+	log.Infof("Refreshed authorized_keys in KDK container [%s]", c.ContainerName())
+	return nil
+}
+
+// Copy the KDK public key into authorized_keys of the KDK user, for images without the KDK bootstrap
+func (c *KdkEnvConfig) copyAuthorizedKey() error {
+	publicKey, err := c.authorizedKeys()
+	if err != nil {
+		return err
+	}
+
+	// Own the files by the KDK user, if the image has `id`.  Otherwise they are owned by root.
+	uid, gid := 0, 0
+	if out, exitCode, err := c.containerExec("", "id", "-u", c.User()); err == nil && exitCode == 0 {
+		uid, _ = strconv.Atoi(strings.TrimSpace(out))
+	}
+	if out, exitCode, err := c.containerExec("", "id", "-g", c.User()); err == nil && exitCode == 0 {
+		gid, _ = strconv.Atoi(strings.TrimSpace(out))
+	}
+	if uid == 0 {
+		log.Warnf("Failed to find the uid of KDK user [%s] in the image.  authorized_keys will be owned by root", c.User())
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: ".ssh/", Typeflag: tar.TypeDir, Mode: 0700, Uid: uid, Gid: gid}); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: ".ssh/authorized_keys", Mode: 0600, Size: int64(len(publicKey)), Uid: uid, Gid: gid}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(publicKey); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := c.DockerClient.CopyToContainer(c.Ctx, c.ContainerName(), c.ContainerHome(), &buf, types.CopyToContainerOptions{}); err != nil {
+		return wrapDockerError(err)
+	}
+	log.Infof("Copied KDK public key to %s/.ssh/authorized_keys", c.ContainerHome())
+	return nil
+}
+
+// Timeout fetching keys from an AuthorizedKeySources URL
+const authorizedKeySourceTimeout = 10 * time.Second
+
+// An authorized key source is github:<user>, gitlab:<user>, an http(s) URL, or a local file
+func validateAuthorizedKeySource(source string) error {
+	switch {
+	case strings.HasPrefix(source, "github:"), strings.HasPrefix(source, "gitlab:"):
+		if user := source[strings.Index(source, ":")+1:]; user == "" || strings.ContainsAny(user, "/ ") {
+			return fmt.Errorf("invalid AuthorizedKeySources [%s]: expected <github|gitlab>:<user>", source)
+		}
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		if _, err := url.Parse(source); err != nil {
+			return fmt.Errorf("invalid AuthorizedKeySources [%s]: %v", source, err)
+		}
+	case source == "":
+		return errors.New("invalid AuthorizedKeySources: empty source")
+	}
+	return nil
+}
+
+// Fetch or read the public keys of an authorized key source
+func fetchAuthorizedKeySource(source string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(source, "github:"):
+		source = "https://github.com/" + strings.TrimPrefix(source, "github:") + ".keys"
+	case strings.HasPrefix(source, "gitlab:"):
+		source = "https://gitlab.com/" + strings.TrimPrefix(source, "gitlab:") + ".keys"
+	case !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://"):
+		file, err := homedir.Expand(source)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadFile(file)
+	}
+
+	client := http.Client{Timeout: authorizedKeySourceTimeout}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// The authorized_keys content: the KDK public key, then the keys of the AuthorizedKeySources.
+// Each key must parse as an ssh public key, and duplicates are dropped.
+func (c *KdkEnvConfig) authorizedKeys() ([]byte, error) {
+	publicKey, err := c.readPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	sources := [][]byte{publicKey}
+	for _, source := range c.ConfigFile.AppConfig.AuthorizedKeySources {
+		keys, err := fetchAuthorizedKeySource(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch authorized keys from [%s]: %v", source, err)
+		}
+		sources = append(sources, keys)
+	}
+
+	var out bytes.Buffer
+	seen := map[string]bool{}
+	for i, keys := range sources {
+		for _, line := range strings.Split(string(keys), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(line))
+			if err != nil {
+				if i == 0 {
+					return nil, fmt.Errorf("invalid KDK public key %s: %v", c.PublicKeyPath(), err)
+				}
+				return nil, fmt.Errorf("invalid public key from [%s]: %v", c.ConfigFile.AppConfig.AuthorizedKeySources[i-1], err)
+			}
+			if fingerprint := gossh.FingerprintSHA256(key); !seen[fingerprint] {
+				seen[fingerprint] = true
+				out.WriteString(line + "\n")
+			}
+		}
+	}
+	return out.Bytes(), nil
+}
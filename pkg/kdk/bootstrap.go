@@ -0,0 +1,125 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Markers written by the KDK bootstrap (provision-user)
+	bootstrapDoneMarker  = "/etc/kdk/provisioned"
+	dotfilesFailedMarker = "/etc/kdk/dotfiles-failed"
+	bootstrapLog         = "/var/log/kdk-provision.log"
+
+	// Default time to wait for the bootstrap to complete
+	BootstrapTimeout = 60 * time.Second
+)
+
+// Wait for the KDK bootstrap to report completion.  User and key provisioning failures fail
+// Provision itself.  A failed dotfiles clone only warns with the tail of the bootstrap log, since
+// the KDK is usable without dotfiles, and the clone is retried on the next start.
+func (c *KdkEnvConfig) WaitForBootstrap(timeout time.Duration) error {
+	check := fmt.Sprintf("if [ -f %s ]; then exit 0; elif [ -f %s ]; then exit 2; else exit 1; fi",
+		bootstrapDoneMarker, dotfilesFailedMarker)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		_, exitCode, err := c.containerExec("", "sh", "-c", check)
+		if err != nil {
+			return err
+		}
+		switch {
+		case exitCode == 0:
+			log.Info("KDK bootstrap complete.")
+			return nil
+		case exitCode == 2:
+			log.Warn(c.bootstrapError(errors.New("KDK dotfiles clone failed.  The KDK is usable without them")))
+			return nil
+		case time.Now().After(deadline):
+			return c.bootstrapError(fmt.Errorf("KDK bootstrap did not complete within %v", timeout))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// Attach the tail of the bootstrap log to err
+func (c *KdkEnvConfig) bootstrapError(err error) error {
+	out, _, logErr := c.containerExec("", "tail", "-n", "50", bootstrapLog)
+	if logErr != nil || out == "" {
+		return err
+	}
+	return fmt.Errorf("%v.  Bootstrap log %s:\n%s", err, bootstrapLog, out)
+}
+
+// Whether the image runs the KDK bootstrap.  A config.yaml written before AppConfig.Bootstrap
+// existed lacks the key, and bootstraps as it always has.
+func (c *KdkEnvConfig) bootstrap() bool {
+	return c.ConfigFile.AppConfig.Bootstrap == nil || *c.ConfigFile.AppConfig.Bootstrap
+}
+
+// Prepare the started KDK container for ssh: run the KDK bootstrap, wait for it to complete, and
+// chown cache volumes and mount targets if configured.  For images without the bootstrap (AppConfig.Bootstrap false), copy in the public key.
+// Then ssh and read-only mounts are verified, status.json is updated, and the post-start hooks run.
+func (c *KdkEnvConfig) Prepare() error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	if err := c.VerifyAuthorizedKey(); err != nil {
+		log.WithField("error", err).Warn("KDK ssh verification failed.  `kdk ssh` is likely to fail")
+	}
+	c.warnWritableMounts()
+	c.writeStatusFile("")
+	return c.runHooks("post-start")
+}
+
+func (c *KdkEnvConfig) prepare() error {
+	// before the bootstrap, which may clone dotfiles through a TLS intercepting proxy
+	if err := c.updateCACerts(); err != nil {
+		log.WithField("error", err).Warn("Failed to add host CA certificates.  TLS verification in the KDK may fail")
+	}
+	if !c.bootstrap() {
+		return c.copyAuthorizedKey()
+	}
+	if err := c.copyBootstrapKey(); err != nil {
+		return err
+	}
+	if err := Provision(*c); err != nil {
+		return err
+	}
+	if err := c.WaitForBootstrap(BootstrapTimeout); err != nil {
+		return err
+	}
+	if idleTimeout := c.ConfigFile.AppConfig.IdleTimeout; idleTimeout != "" {
+		log.Infof("KDK container stops after %s without ssh sessions (IdleTimeout).  Monitor log: /var/log/kdk-idle-monitor.log", idleTimeout)
+	}
+	// the bootstrap authorizes only the KDK public key
+	if len(c.ConfigFile.AppConfig.AuthorizedKeySources) > 0 {
+		if err := c.RefreshAuthorizedKeys(); err != nil {
+			return err
+		}
+	}
+	if err := c.chownCacheVolumes(); err != nil {
+		return err
+	}
+	if c.ConfigFile.AppConfig.ChownMounts {
+		return c.chownMounts()
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"github.com/codeskyblue/go-sh"
+	"github.com/docker/go-connections/nat"
+	log "github.com/sirupsen/logrus"
+)
+
+// Host port published for a KDK container tcp port.  The running container is preferred, since
+// docker may assign the host port, then the config.
+func (c *KdkEnvConfig) publishedPort(containerPort int) (string, error) {
+	port, err := nat.NewPort("tcp", strconv.Itoa(containerPort))
+	if err != nil {
+		return "", err
+	}
+	if inspect, err := c.Inspect(); err == nil && inspect.NetworkSettings != nil {
+		for _, binding := range inspect.NetworkSettings.Ports[port] {
+			if binding.HostPort != "" {
+				return binding.HostPort, nil
+			}
+		}
+	}
+	if c.ConfigFile.HostConfig != nil {
+		for _, binding := range c.ConfigFile.HostConfig.PortBindings[port] {
+			if binding.HostPort != "" {
+				return binding.HostPort, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("KDK container port [%d] is not published", containerPort)
+}
+
+// Open the default browser to the host port published for a KDK container port
+func (c *KdkEnvConfig) OpenBrowser(containerPort int) error {
+	hostPort, err := c.publishedPort(containerPort)
+	if err != nil {
+		return err
+	}
+	url := "http://localhost:" + hostPort
+	log.Infof("Opening %s", url)
+
+	switch runtime.GOOS {
+	case "darwin":
+		return sh.Command("open", url).Run()
+	case "windows":
+		return sh.Command("cmd", "/c", "start", "", url).Run()
+	default:
+		return sh.Command("xdg-open", url).Run()
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/jsonmessage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Dockerfile ARG names
+var buildArgPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Build args must be valid Dockerfile ARG names
+func validateBuildArgs(buildArgs map[string]string) error {
+	for name := range buildArgs {
+		if !buildArgPattern.MatchString(name) {
+			return fmt.Errorf("invalid BuildArgs: [%s] is not a valid Dockerfile ARG name", name)
+		}
+	}
+	return nil
+}
+
+// Build a custom KDK image from the BuildContext directory, tagged as the configured image so that
+// the next recreate uses it.  BuildArgs are passed to the build.  Docker ignores args that the
+// Dockerfile doesn't declare with ARG.
+func (c *KdkEnvConfig) BuildImage() error {
+	buildContext := c.ConfigFile.AppConfig.BuildContext
+	if buildContext == "" {
+		return errors.New("no BuildContext is configured, set one with `kdk init --build-context`")
+	}
+	if err := validateBuildArgs(c.ConfigFile.AppConfig.BuildArgs); err != nil {
+		return err
+	}
+
+	tar, err := archive.TarWithOptions(buildContext, &archive.TarOptions{})
+	if err != nil {
+		return err
+	}
+	defer tar.Close()
+
+	buildArgs := map[string]*string{}
+	for name, value := range c.ConfigFile.AppConfig.BuildArgs {
+		value := value
+		buildArgs[name] = &value
+	}
+	log.Infof("Building KDK image [%s] from %s", c.ImageCoordinates(), buildContext)
+	response, err := c.DockerClient.ImageBuild(c.Ctx, tar, types.ImageBuildOptions{
+		Tags:        []string{c.ImageCoordinates()},
+		BuildArgs:   buildArgs,
+		Platform:    c.ConfigFile.AppConfig.Platform,
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	defer response.Body.Close()
+
+	outStream := command.NewOutStream(os.Stdout)
+	return jsonmessage.DisplayJSONMessagesToStream(response.Body, outStream, nil)
+}
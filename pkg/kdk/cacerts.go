@@ -0,0 +1,88 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Host CA bundle locations, in order of preference.  The macOS bundle lacks CAs added to the
+// keychain, which may be exported to a file for HostCACertsPath.
+var hostCACertBundles = map[string][]string{
+	"linux": {
+		"/etc/ssl/certs/ca-certificates.crt", // Debian, Ubuntu, Gentoo
+		"/etc/pki/tls/certs/ca-bundle.crt",   // Fedora, RHEL, CentOS
+		"/etc/ssl/ca-bundle.pem",             // openSUSE
+		"/etc/ssl/cert.pem",                  // Alpine, Arch
+	},
+	"darwin": {"/etc/ssl/cert.pem"},
+}
+
+// The host bundle is added to the image's local CAs, which update-ca-certificates merges into
+// the system trust store.  Mounting over the trust store itself would make it read-only.
+const caCertsTarget = "/usr/local/share/ca-certificates/kdk-host-ca-certificates.crt"
+
+// The host CA bundle: HostCACertsPath if set, otherwise the first bundle found for the host OS
+func hostCACertBundle(override string) (string, error) {
+	if override != "" {
+		if _, err := os.Stat(override); err != nil {
+			return "", fmt.Errorf("invalid HostCACertsPath [%s]: %v", override, err)
+		}
+		return override, nil
+	}
+	for _, bundle := range hostCACertBundles[runtime.GOOS] {
+		if _, err := os.Stat(bundle); err == nil {
+			return bundle, nil
+		}
+	}
+	return "", fmt.Errorf("invalid MountHostCACerts: no host CA bundle found (tried %s).  Set HostCACertsPath",
+		strings.Join(hostCACertBundles[runtime.GOOS], ", "))
+}
+
+// Read-only mount of the host CA bundle, if MountHostCACerts.  The bundle is looked up only here
+// at init; a bundle missing later fails the container create, as any missing bind mount source.
+func (c *KdkEnvConfig) caCertsMount() (mount.Mount, bool, error) {
+	if !c.ConfigFile.AppConfig.MountHostCACerts {
+		return mount.Mount{}, false, nil
+	}
+	bundle, err := hostCACertBundle(c.ConfigFile.AppConfig.HostCACertsPath)
+	if err != nil {
+		return mount.Mount{}, false, err
+	}
+	log.Infof("Mounting host CA bundle %s read-only at %s", bundle, caCertsTarget)
+	return mount.Mount{Type: mount.TypeBind, Source: bundle, Target: caCertsTarget, ReadOnly: true}, true, nil
+}
+
+// Add the mounted host CA bundle to the trust store of the started KDK container
+func (c *KdkEnvConfig) updateCACerts() error {
+	if !c.ConfigFile.AppConfig.MountHostCACerts {
+		return nil
+	}
+	out, exitCode, err := c.containerExec("root", "sh", "-c", "command -v update-ca-certificates >/dev/null && update-ca-certificates")
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("update-ca-certificates failed or is not installed in the KDK image [exit %d]: %s", exitCode, strings.TrimSpace(out))
+	}
+	log.Info("Added host CA certificates to the KDK trust store.")
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Dependency cache directories by language.  Relative paths are under the KDK user home.
+var cacheVolumeTargets = map[string]string{
+	"go":     "/go/pkg/mod",
+	"npm":    ".npm",
+	"yarn":   ".cache/yarn",
+	"pip":    ".cache/pip",
+	"maven":  ".m2",
+	"gradle": ".gradle",
+	"cargo":  ".cargo/registry",
+}
+
+// Cache volumes must name known languages
+func validateCacheVolumes(langs []string) error {
+	for _, lang := range langs {
+		if _, ok := cacheVolumeTargets[lang]; !ok {
+			var known []string
+			for name := range cacheVolumeTargets {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("invalid CacheVolumes [%s]: must be one of %s", lang, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// In-container path of the cache volume of lang
+func (c *KdkEnvConfig) cacheVolumeTarget(lang string) string {
+	target := cacheVolumeTargets[lang]
+	if !path.IsAbs(target) {
+		target = path.Join(c.ContainerHome(), target)
+	}
+	return target
+}
+
+// Named volume mounts for the configured dependency caches.  The volumes are named after the
+// container, so they outlive `kdk recreate` but are not shared between environments.
+func (c *KdkEnvConfig) cacheVolumeMounts() (mounts []mount.Mount) {
+	for _, lang := range c.ConfigFile.AppConfig.CacheVolumes {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: c.ContainerName() + "-cache-" + lang,
+			Target: c.cacheVolumeTarget(lang),
+		})
+	}
+	return mounts
+}
+
+// Docker creates new volumes owned by root, so hand the cache volumes to the KDK user
+func (c *KdkEnvConfig) chownCacheVolumes() error {
+	var targets []string
+	for _, lang := range c.ConfigFile.AppConfig.CacheVolumes {
+		targets = append(targets, c.cacheVolumeTarget(lang))
+	}
+	return c.chownTargets(targets)
+}
@@ -16,13 +16,17 @@ package kdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/user"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cisco-sso/kdk/pkg/keybase"
 	"github.com/cisco-sso/kdk/pkg/prompt"
@@ -32,8 +36,10 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 	"github.com/ghodss/yaml"
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
@@ -45,10 +51,21 @@ var (
 )
 
 type KdkEnvConfig struct {
-	DockerClient *client.Client
-	Ctx          context.Context
-	ConfigFile   configFile
-	SocksPort    string
+	DockerClient  *client.Client
+	Ctx           context.Context
+	ConfigFile    configFile
+	SocksPort     string
+	ForceLocked   bool   // allow overwriting a Locked config
+	DockerContext string // docker cli context name
+	HomeDir       string // overrides the users home directory, which holds ~/.kdk (e.g. a temp dir in tests)
+	// Docker API request timeout (0 for none) and TCP keepalive period of the docker client
+	DockerTimeout   time.Duration
+	DockerKeepAlive time.Duration
+	// Operate from ConfigFile and PublicKeyData alone, reading and writing nothing under ~/.kdk, e.g.
+	//   when embedding kdk as a library.  Start, ContainerExec, and Destroy are supported.  Start still
+	//   prompts on stdin when the KDK container exists but has exited (see Up).
+	InMemory      bool
+	PublicKeyData []byte // KDK ssh public key, instead of the key under ~/.kdk/ssh
 }
 
 // Struct of all configs to be saved directly as ~/.kdk/<NAME>/config.yaml
@@ -59,25 +76,97 @@ type configFile struct {
 }
 
 type AppConfig struct {
-	Name            string
-	Port            string
-	ImageRepository string
-	ImageTag        string
-	DotfilesRepo    string
-	Shell           string
-	SocksPort       string
+	Name                 string
+	ContainerName        string
+	Template             string `json:",omitempty"`
+	Hostname             string
+	Domainname           string
+	DnsSearch            []string `json:",omitempty"`
+	Port                 string
+	HostIP               string
+	SocketOnly           bool
+	ImageRepository      string
+	ImageTag             string
+	ImageTags            map[string]string `json:",omitempty"`
+	Platform             string
+	BuildContext         string            `json:",omitempty"`
+	BuildArgs            map[string]string `json:",omitempty"`
+	DotfilesRepo         string
+	Shell                string
+	SocksPort            string
+	KeepAlive            bool
+	Init                 bool
+	Tty                  bool
+	ShmSize              string
+	AutoRemove           bool
+	Labels               map[string]string `json:",omitempty"`
+	CgroupParent         string
+	MountCommonDotfiles  bool
+	MountHostCACerts     bool
+	HostCACertsPath      string   `json:",omitempty"`
+	KeybasePaths         []string `json:",omitempty"`
+	SshdConfig           string
+	MountProfile         string            `json:",omitempty"`
+	RemoteSync           *RemoteSyncConfig `json:",omitempty"`
+	Kubeconfig           string            `json:",omitempty"`
+	KubeContexts         []string          `json:",omitempty"`
+	LogDriver            string
+	LogOptions           map[string]string `json:",omitempty"`
+	Locked               bool
+	Network              string
+	NetworkAliases       []string `json:",omitempty"`
+	SyncTimezone         bool
+	Locale               string
+	MatchHostUid         bool
+	Sidecars             []SidecarSpec `json:",omitempty"`
+	EnvFile              string
+	Relabel              string
+	Privileged           bool
+	UsernsMode           string
+	Memory               string
+	MemorySwap           string
+	StopSignal           string `json:",omitempty"`
+	StopTimeout          int
+	CpusetCpus           string
+	CpuShares            int64
+	OomScoreAdj          int
+	OomKillDisable       bool
+	Bootstrap            *bool `json:",omitempty"`
+	ChownMounts          bool
+	Hooks                *HooksConfig `json:",omitempty"`
+	CacheVolumes         []string     `json:",omitempty"`
+	PersistHome          string
+	Proxy                *ProxyConfig `json:",omitempty"`
+	AuthorizedKeySources []string     `json:",omitempty"`
+	KeyProvider          string
+	IdleTimeout          string
 }
 
 // create docker client and context for easy reuse
-func (c *KdkEnvConfig) Init() {
+func (c *KdkEnvConfig) Init() error {
 	c.Ctx = context.Background()
-	dockerClient, err := client.NewEnvClient()
+
+	// Connect to the daemon of a docker cli context, if any, otherwise as configured by DOCKER_HOST etc
+	var dockerClient *client.Client
+	var err error
+	if dockerContext := resolveDockerContext(c.DockerContext); dockerContext != "" {
+		var opts []client.Opt
+		if opts, err = dockerContextOpts(dockerContext); err != nil {
+			return wrapError(ErrDockerUnavailable, err)
+		}
+		dockerClient, err = client.NewClientWithOpts(opts...)
+	} else {
+		dockerClient, err = client.NewEnvClient()
+	}
+	if err == nil {
+		err = c.configureDockerClient(dockerClient)
+	}
 	if err != nil {
-		log.Warn("Failed to create docker client.")
-		log.Fatal("Ensure that docker is running.")
+		return wrapError(ErrDockerUnavailable, err)
 	}
 
 	c.DockerClient = dockerClient
+	return nil
 }
 
 // current username
@@ -94,8 +183,11 @@ func (c *KdkEnvConfig) User() (out string) {
 	return username
 }
 
-// users home directory
+// users home directory, or HomeDir if set
 func (c *KdkEnvConfig) Home() (out string) {
+	if c.HomeDir != "" {
+		return c.HomeDir
+	}
 	out, err := homedir.Dir()
 	if err != nil {
 		panic(err)
@@ -103,6 +195,11 @@ func (c *KdkEnvConfig) Home() (out string) {
 	return out
 }
 
+// KDK users home directory within the container (/home/<username>)
+func (c *KdkEnvConfig) ContainerHome() (out string) {
+	return path.Join("/home", c.User())
+}
+
 // kdk root config path (~/.kdk)
 func (c *KdkEnvConfig) ConfigRootDir() (out string) {
 	return filepath.Join(c.Home(), ".kdk")
@@ -133,29 +230,178 @@ func (c *KdkEnvConfig) ConfigPath() (out string) {
 	return filepath.Join(c.ConfigDir(), "config.yaml")
 }
 
+// kdk docker container name (defaults to <KDK_NAME>)
+func (c *KdkEnvConfig) ContainerName() (out string) {
+	if c.ConfigFile.AppConfig.ContainerName != "" {
+		return c.ConfigFile.AppConfig.ContainerName
+	}
+	return c.ConfigFile.AppConfig.Name
+}
+
+// kdk container hostname (defaults to <KDK_NAME>)
+func (c *KdkEnvConfig) hostname() (out string) {
+	if c.ConfigFile.AppConfig.Hostname != "" {
+		return c.ConfigFile.AppConfig.Hostname
+	}
+	return c.ConfigFile.AppConfig.Name
+}
+
 // kdk image coordinates (ciscosso/kdk:debian-latest)
 func (c *KdkEnvConfig) ImageCoordinates() (out string) {
-	return c.ConfigFile.AppConfig.ImageRepository + ":" + c.ConfigFile.AppConfig.ImageTag
+	return c.ConfigFile.AppConfig.ImageRepository + ":" + c.ImageTag()
+}
+
+// Load the kdk container config from ~/.kdk/<KDK_NAME>/config.yaml
+func (c *KdkEnvConfig) LoadKdkConfig() error {
+	data, err := c.readConfig()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return wrapError(ErrConfigNotFound, err)
+		}
+		return err
+	}
+	if err := yaml.Unmarshal(data, &c.ConfigFile); err != nil {
+		return err
+	}
+	if err := c.normalizePorts(); err != nil {
+		return err
+	}
+	if err := c.normalizeMounts(); err != nil {
+		return err
+	}
+	return c.validateConfig()
+}
+
+// Refuse to overwrite a config.yaml that is Locked, e.g. one distributed by fleet admins,
+// unless ForceLocked is set
+func (c *KdkEnvConfig) checkLocked() error {
+	if c.ForceLocked {
+		return nil
+	}
+	data, err := c.readConfig()
+	if err != nil {
+		return nil
+	}
+	var existing configFile
+	if err := yaml.Unmarshal(data, &existing); err == nil && existing.AppConfig.Locked {
+		return wrapError(ErrConfigLocked, fmt.Errorf("refusing to overwrite %s", c.ConfigSource()))
+	}
+	return nil
+}
+
+// Save the kdk container config to ~/.kdk/<KDK_NAME>/config.yaml
+func (c *KdkEnvConfig) SaveKdkConfig() error {
+	if err := c.checkLocked(); err != nil {
+		return err
+	}
+	return c.saveConfig()
+}
+
+// Save the kdk container config regardless of Locked, for changes kdk itself must persist
+func (c *KdkEnvConfig) saveConfig() error {
+	y, err := yaml.Marshal(&c.ConfigFile)
+	if err != nil {
+		return err
+	}
+	return c.writeConfig(y)
+}
+
+// Apply a complete config.yaml read from r, writing it to the config dir of the environment it names
+func (c *KdkEnvConfig) ApplyConfig(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var config configFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	if err := validateName(config.AppConfig.Name); err != nil {
+		return err
+	}
+	if config.ContainerConfig == nil || config.HostConfig == nil {
+		return errors.New("invalid config: ContainerConfig and HostConfig are required")
+	}
+
+	c.ConfigFile = config
+	if err := c.normalizePorts(); err != nil {
+		return err
+	}
+	if err := c.normalizeMounts(); err != nil {
+		return err
+	}
+	if err := c.validateConfig(); err != nil {
+		return err
+	}
+
+	if err := mkdirPolicy(c.ConfigDir()); err != nil {
+		return err
+	}
+	return c.SaveKdkConfig()
 }
 
 func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 
 	// Initialize storage mounts/volumes
 	var mounts []mount.Mount         // hostConfig
+	var binds []string               // hostConfig, for SELinux relabeled mounts
 	volumes := map[string]struct{}{} // containerConfig
-	labels := map[string]string{"kdk": Version}
+	labels := map[string]string{versionLabel: Version}
+
+	if err := c.checkLocked(); err != nil {
+		return err
+	}
+	if err := validateRelabel(c.ConfigFile.AppConfig.Relabel); err != nil {
+		return err
+	}
+
+	if err := c.normalizePorts(); err != nil {
+		return err
+	}
+	c.ensureUniquePort()
+
+	// Parse the human readable /dev/shm size (e.g. "1g").  Empty uses the docker default
+	var shmSize int64
+	if c.ConfigFile.AppConfig.ShmSize != "" {
+		if shmSize, err = units.RAMInBytes(c.ConfigFile.AppConfig.ShmSize); err != nil {
+			return fmt.Errorf("invalid ShmSize [%s]: %v", c.ConfigFile.AppConfig.ShmSize, err)
+		}
+	}
+
+	// Memory limits, human readable like ShmSize.  MemorySwap of -1 allows unlimited swap
+	memory, memorySwap, err := c.memoryLimits()
+	if err != nil {
+		return err
+	}
+
+	// CPU pinning must name CPUs the docker daemon has
+	if err := validateCpuset(c.ConfigFile.AppConfig.CpusetCpus, c.daemonCPUs()); err != nil {
+		return err
+	}
 
 	// Define mount configurations for mounting the ssh pub key into a tmp location where the bootstrap script may
 	//   copy into <userdir>/.ssh/authorized keys.  This is required because Windows mounts squash permissions to
 	//   777 which makes ssh fail a strict check on pubkey permissions.
+	//   Images without the bootstrap have the key copied directly into authorized_keys at start instead.
 	source := c.PublicKeyPath()
 	target := "/tmp/id_rsa.pub"
-	mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target, ReadOnly: true})
-	volumes[target] = struct{}{}
+	if c.bootstrap() {
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target, ReadOnly: true})
+		volumes[target] = struct{}{}
+	}
 
-	// Keybase mounts
-	source, target, err = keybase.GetMounts(c.ConfigRootDir())
-	if err != nil {
+	// Keybase mounts.  Mount only the selected keybase paths, if any, otherwise offer the whole filesystem
+	if len(c.ConfigFile.AppConfig.KeybasePaths) > 0 {
+		keybaseMounts, err := keybase.GetPathMounts(c.ConfigRootDir(), c.ConfigFile.AppConfig.KeybasePaths)
+		if err != nil {
+			return err
+		}
+		for _, m := range keybaseMounts {
+			mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: m.Source, Target: m.Target,
+				ReadOnly: false, Consistency: mount.ConsistencyCached})
+			volumes[m.Target] = struct{}{}
+		}
+	} else if source, target, err = keybase.GetMounts(c.ConfigRootDir()); err != nil {
 		log.Warn("Failed to add keybase mount:", err)
 	} else {
 		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target,
@@ -163,6 +409,120 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 		volumes[target] = struct{}{}
 	}
 
+	// Common dotfiles mounts (git identity and cloud credentials)
+	if c.ConfigFile.AppConfig.MountCommonDotfiles {
+		for _, m := range c.commonDotfileMounts() {
+			mounts = append(mounts, m)
+			volumes[m.Target] = struct{}{}
+		}
+	}
+
+	// The KDK user home, persisted on the host or in a named volume across recreates
+	homeMount, persistHome, err := c.persistHomeMount()
+	if err != nil {
+		return err
+	}
+	if persistHome {
+		if bind, ok := c.relabeledBind(homeMount, c.ConfigFile.AppConfig.Relabel); ok {
+			binds = append(binds, bind)
+		} else {
+			mounts = append(mounts, homeMount)
+		}
+		volumes[homeMount.Target] = struct{}{}
+	}
+
+	// Dependency caches, persisted in named volumes across recreates
+	for _, m := range c.cacheVolumeMounts() {
+		mounts = append(mounts, m)
+		volumes[m.Target] = struct{}{}
+	}
+
+	// Starter mounts and environment of the template, if any.  Its init flags were applied by the caller.
+	var template Template
+	if c.ConfigFile.AppConfig.Template != "" {
+		if template, err = c.Template(c.ConfigFile.AppConfig.Template); err != nil {
+			return err
+		}
+		templateMounts, err := c.templateMounts(template)
+		if err != nil {
+			return err
+		}
+		for _, m := range templateMounts {
+			mounts = append(mounts, m)
+			volumes[m.Target] = struct{}{}
+		}
+	}
+
+	// Host timezone and locale
+	tzMounts, tzEnv := c.timezoneLocale()
+	for _, m := range tzMounts {
+		mounts = append(mounts, m)
+		volumes[m.Target] = struct{}{}
+	}
+
+	// Environment file, checked here for `kdk init`, and re-read at each create (EffectiveConfig) rather
+	//   than on every load, so that a moved file doesn't break other commands
+	if c.ConfigFile.AppConfig.EnvFile != "" {
+		if c.ConfigFile.AppConfig.EnvFile, err = homedir.Expand(c.ConfigFile.AppConfig.EnvFile); err != nil {
+			return err
+		}
+		if _, err := parseEnvFile(c.ConfigFile.AppConfig.EnvFile); err != nil {
+			return err
+		}
+	}
+
+	// Custom image build context, resolved so that `kdk build` works from any directory
+	if c.ConfigFile.AppConfig.BuildContext != "" {
+		if c.ConfigFile.AppConfig.BuildContext, err = homedir.Expand(c.ConfigFile.AppConfig.BuildContext); err != nil {
+			return err
+		}
+		if c.ConfigFile.AppConfig.BuildContext, err = filepath.Abs(c.ConfigFile.AppConfig.BuildContext); err != nil {
+			return err
+		}
+	}
+
+	// Host kubeconfig, optionally filtered to selected contexts
+	if c.ConfigFile.AppConfig.Kubeconfig != "" {
+		if c.ConfigFile.AppConfig.Kubeconfig, err = homedir.Expand(c.ConfigFile.AppConfig.Kubeconfig); err != nil {
+			return err
+		}
+		if err := validateKubeconfig(c.ConfigFile.AppConfig.Kubeconfig, c.ConfigFile.AppConfig.KubeContexts); err != nil {
+			return err
+		}
+		kubeMount, _, err := c.kubeconfigMount()
+		if err != nil {
+			return err
+		}
+		mounts = append(mounts, kubeMount)
+		volumes[kubeMount.Target] = struct{}{}
+	}
+
+	// Host CA certificates, e.g. of a TLS intercepting proxy
+	if c.ConfigFile.AppConfig.HostCACertsPath != "" {
+		if c.ConfigFile.AppConfig.HostCACertsPath, err = homedir.Expand(c.ConfigFile.AppConfig.HostCACertsPath); err != nil {
+			return err
+		}
+	}
+	if caMount, ok, err := c.caCertsMount(); err != nil {
+		return err
+	} else if ok {
+		mounts = append(mounts, caMount)
+		volumes[caMount.Target] = struct{}{}
+	}
+
+	// sshd config drop-in, e.g. to enforce ciphers and MACs without rebuilding the image
+	if c.ConfigFile.AppConfig.SshdConfig != "" {
+		if c.ConfigFile.AppConfig.SshdConfig, err = homedir.Expand(c.ConfigFile.AppConfig.SshdConfig); err != nil {
+			return err
+		}
+		if err := validateSshdConfig(c.ConfigFile.AppConfig.SshdConfig); err != nil {
+			return err
+		}
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: c.ConfigFile.AppConfig.SshdConfig,
+			Target: sshdConfigTarget, ReadOnly: true})
+		volumes[sshdConfigTarget] = struct{}{}
+	}
+
 	// Define Additional volume bindings
 	for {
 		prmpt := prompt.Prompt{
@@ -175,6 +535,7 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 				Text:     "Please enter the docker host source directory (e.g. /Users/<username>/Projects) ",
 				Loop:     true,
 				Validate: prompt.ValidateDirExists,
+				Default:  c.defaultMountSource(),
 			}
 			source, err := prmpt.Run()
 			if err == nil {
@@ -185,14 +546,20 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 				Text:     "Please enter the docker container target directory (e.g. /home/<username>/Projects) ",
 				Loop:     false,
 				Validate: nil,
+				Default:  c.defaultMountTarget(source),
 			}
 			target, err := prmpt.Run()
 			if err == nil {
 				log.Infof("Entered container target directory mount %v", target)
 			}
 
-			mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target,
-				ReadOnly: false, Consistency: mount.ConsistencyCached})
+			m := mount.Mount{Type: mount.TypeBind, Source: source, Target: target,
+				ReadOnly: false, Consistency: mount.ConsistencyCached}
+			if bind, ok := c.relabeledBind(m, c.ConfigFile.AppConfig.Relabel); ok {
+				binds = append(binds, bind)
+			} else {
+				mounts = append(mounts, m)
+			}
 			volumes[target] = struct{}{}
 		} else {
 			break
@@ -209,9 +576,10 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 		}
 		if result, err := prmpt.Run(); err == nil && result == "y" {
 			prmpt = prompt.Prompt{
-				Text:     "Please enter SOCKS port number [8000] ",
+				Text:     "Please enter SOCKS port number ",
 				Loop:     true,
 				Validate: prompt.ValidateIntOrEmptyString,
+				Default:  "8000",
 			}
 			socksPort, _ = prmpt.Run()
 		}
@@ -224,38 +592,129 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 	}
 	log.Infof("Set SOCKS port %v", c.ConfigFile.AppConfig.SocksPort)
 
+	// Warn when the KDK ssh port is reachable from other hosts
+	if c.ConfigFile.AppConfig.HostIP == "0.0.0.0" || c.ConfigFile.AppConfig.HostIP == "" {
+		log.Warn("KDK ssh port will be published on all host interfaces and reachable from the network")
+	}
+
+	// Bound the container logs by default, since the KDK is long-lived
+	logOptions := c.ConfigFile.AppConfig.LogOptions
+	if len(logOptions) == 0 && (c.ConfigFile.AppConfig.LogDriver == "json-file" || c.ConfigFile.AppConfig.LogDriver == "local") {
+		logOptions = map[string]string{"max-size": "10m", "max-file": "3"}
+	}
+
 	// Create the Default configuration struct that will be written as the config file
 	c.ConfigFile.ContainerConfig = &container.Config{
-		Hostname: c.ConfigFile.AppConfig.Name,
-		Image:    c.ImageCoordinates(),
-		Tty:      true,
-		Env: []string{
+		Hostname:   c.hostname(),
+		Domainname: c.ConfigFile.AppConfig.Domainname,
+		Image:      c.ImageCoordinates(),
+		Tty:        c.ConfigFile.AppConfig.Tty,
+		ExposedPorts: nat.PortSet{
+			"2022/tcp": struct{}{},
+		},
+		Volumes:    volumes,
+		Labels:     mergeLabels(labels, c.ConfigFile.AppConfig.Labels),
+		StopSignal: c.ConfigFile.AppConfig.StopSignal,
+	}
+	if c.ConfigFile.AppConfig.StopTimeout > 0 {
+		stopTimeout := c.ConfigFile.AppConfig.StopTimeout
+		c.ConfigFile.ContainerConfig.StopTimeout = &stopTimeout
+	}
+
+	// Settings consumed by the KDK bootstrap (provision-user)
+	if c.bootstrap() {
+		c.ConfigFile.ContainerConfig.Env = []string{
 			"KDK_USERNAME=" + c.User(),
 			"KDK_SHELL=" + c.ConfigFile.AppConfig.Shell,
 			"KDK_DOTFILES_REPO=" + c.ConfigFile.AppConfig.DotfilesRepo,
+		}
+		c.ConfigFile.ContainerConfig.Env = append(c.ConfigFile.ContainerConfig.Env, c.idleTimeoutEnv()...)
+		if persistHome {
+			c.ConfigFile.ContainerConfig.Env = append(c.ConfigFile.ContainerConfig.Env, "KDK_PERSIST_HOME=true")
+		}
+	}
+	c.ConfigFile.ContainerConfig.Env = append(c.ConfigFile.ContainerConfig.Env, tzEnv...)
+	c.ConfigFile.ContainerConfig.Env = append(c.ConfigFile.ContainerConfig.Env, template.Env...)
+
+	// Hold the container open for images without a long-running process
+	if c.ConfigFile.AppConfig.KeepAlive {
+		c.ConfigFile.ContainerConfig.Cmd = strslice.StrSlice{"tail", "-f", "/dev/null"}
+	}
+
+	// Rootless docker shifts container uids on the host.  The pubkey copy into authorized_keys is unaffected
+	//   since the bootstrap reads the mount as container root, which is the host user.
+	containerUID := kdkUserUID
+	if c.ConfigFile.AppConfig.MatchHostUid {
+		// The bootstrap creates the KDK user with the host uid/gid, so files written to host mounts
+		//   are owned by the host user.  The pubkey copy runs as root and is unaffected.
+		if uid, gid := os.Getuid(), os.Getgid(); uid < 0 || gid < 0 {
+			log.Warn("Host uid/gid are unavailable on this platform.  Ignoring MatchHostUid")
+		} else if uid == 0 {
+			// The image already has root, so the KDK user can't be created with uid 0, e.g. under sudo
+			return errors.New("invalid MatchHostUid: the host uid is 0 (root).  Run kdk init as your own user")
+		} else {
+			containerUID = uid
+			c.ConfigFile.ContainerConfig.Env = append(c.ConfigFile.ContainerConfig.Env,
+				"KDK_UID="+strconv.Itoa(uid), "KDK_GID="+strconv.Itoa(gid))
+		}
+	}
+	if c.IsRootless() {
+		c.warnRootless(containerUID)
+	}
+	// A socket only KDK publishes no port.  ssh reaches the container sshd through `kdk ssh-proxy`.
+	portBindings := nat.PortMap{
+		"2022/tcp": []nat.PortBinding{
+			{
+				HostIP:   c.ConfigFile.AppConfig.HostIP,
+				HostPort: c.ConfigFile.AppConfig.Port,
+			},
 		},
-		ExposedPorts: nat.PortSet{
-			"2022/tcp": struct{}{},
-		},
-		Volumes: volumes,
-		Labels:  labels,
 	}
+	if c.ConfigFile.AppConfig.SocketOnly {
+		portBindings = nil
+	}
+	// Run docker's init (tini) as pid 1, so that signals reach the entrypoint and zombies are reaped
+	useInit := c.ConfigFile.AppConfig.Init
 	c.ConfigFile.HostConfig = &container.HostConfig{
 		// TODO (rluckie): shouldn't default to privileged -- issue with ssh cmd
-		Privileged: true,
-		PortBindings: nat.PortMap{
-			"2022/tcp": []nat.PortBinding{
-				{
-					HostPort: c.ConfigFile.AppConfig.Port,
-				},
-			},
+		Privileged:   c.ConfigFile.AppConfig.Privileged,
+		UsernsMode:   container.UsernsMode(c.ConfigFile.AppConfig.UsernsMode),
+		Init:         &useInit,
+		PortBindings: portBindings,
+		Mounts:       mounts,
+		Binds:        binds,
+		ShmSize:      shmSize,
+		AutoRemove:   c.ConfigFile.AppConfig.AutoRemove,
+		CgroupParent: c.ConfigFile.AppConfig.CgroupParent,
+		DNSSearch:    c.ConfigFile.AppConfig.DnsSearch,
+		OomScoreAdj:  c.ConfigFile.AppConfig.OomScoreAdj,
+		NetworkMode:  container.NetworkMode(c.ConfigFile.AppConfig.Network),
+		Resources: container.Resources{
+			Memory:     memory,
+			MemorySwap: memorySwap,
+			CpusetCpus: c.ConfigFile.AppConfig.CpusetCpus,
+			CPUShares:  c.ConfigFile.AppConfig.CpuShares,
 		},
-		Mounts: mounts,
+		LogConfig: container.LogConfig{
+			Type:   c.ConfigFile.AppConfig.LogDriver,
+			Config: logOptions,
+		},
+	}
+	if c.ConfigFile.AppConfig.OomKillDisable {
+		oomKillDisable := true
+		c.ConfigFile.HostConfig.OomKillDisable = &oomKillDisable
+	}
+
+	if err := c.validateConfig(); err != nil {
+		return err
+	}
+	if c.ConfigFile.AppConfig.Privileged && c.ConfigFile.AppConfig.UsernsMode == "" && c.IsUsernsRemap() {
+		return errors.New("invalid UsernsMode: the docker daemon remaps user namespaces, which is incompatible with Privileged.  Use --userns-mode host, or --privileged=false")
 	}
 
 	// Ensure that the ~/.kdk directory exists
 	if _, err := os.Stat(c.ConfigRootDir()); os.IsNotExist(err) {
-		if err := os.Mkdir(c.ConfigRootDir(), 0700); err != nil {
+		if err := mkdirPolicy(c.ConfigRootDir()); err != nil {
 			log.WithField("error", err).Fatalf("Failed to create KDK config directory [%s]", c.ConfigRootDir())
 			return err
 		}
@@ -263,7 +722,7 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 
 	// Ensure that the ~/.kdk/<kdkName> directory exists
 	if _, err := os.Stat(c.ConfigDir()); os.IsNotExist(err) {
-		if err := os.Mkdir(c.ConfigDir(), 0700); err != nil {
+		if err := mkdirPolicy(c.ConfigDir()); err != nil {
 			log.WithField("error", err).Fatalf("Failed to create KDK config directory", filepath.Dir(c.ConfigDir()))
 			return err
 		}
@@ -272,15 +731,22 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 	// Create the ~/.kdk/<kdkName>/config.yaml file if it doesn't exist
 	y, err := yaml.Marshal(&c.ConfigFile)
 	if err != nil {
-		log.Fatal("Failed to create YAML string of configuration", err)
+		log.WithField("error", err).Fatal("Failed to create YAML string of configuration")
 	}
-	if _, err := os.Stat(c.ConfigPath()); os.IsNotExist(err) {
+	if existing, err := c.readConfig(); os.IsNotExist(err) {
 		log.Warn("KDK config does not exist")
 		log.Info("Creating KDK config")
 
-		ioutil.WriteFile(c.ConfigPath(), y, 0600)
+		writeFilePolicy(c.ConfigPath(), y)
 	} else {
 		log.Warn("KDK config exists")
+		if err == nil {
+			if diff := formatDiff(lineDiff(string(existing), string(y))); diff == "" {
+				log.Info("New KDK config is identical to the existing config")
+			} else {
+				fmt.Printf("Changes to %s:\n%s", c.ConfigSource(), diff)
+			}
+		}
 		prmpt := prompt.Prompt{
 			Text:     "Overwrite existing KDK config? [y/n] ",
 			Loop:     true,
@@ -288,7 +754,7 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 		}
 		if result, err := prmpt.Run(); err == nil && result == "y" {
 			log.Info("Creating KDK config")
-			ioutil.WriteFile(c.ConfigPath(), y, 0600)
+			c.writeConfig(y)
 		} else {
 			log.Info("Existing KDK config not overwritten")
 			return err
@@ -297,16 +763,34 @@ func (c *KdkEnvConfig) CreateKdkConfig() (err error) {
 	return nil
 }
 
+// default host directory offered for additional mounts (~/Projects if present, otherwise ~)
+func (c *KdkEnvConfig) defaultMountSource() string {
+	projects := filepath.Join(c.Home(), "Projects")
+	if _, err := os.Stat(projects); err == nil {
+		return projects
+	}
+	return c.Home()
+}
+
+// Default container mount target for a host source directory, under the KDK user home
+func (c *KdkEnvConfig) defaultMountTarget(source string) string {
+	base := filepath.Base(source)
+	if source == "" || base == "." || base == string(filepath.Separator) {
+		base = "Projects"
+	}
+	return path.Join(c.ContainerHome(), base)
+}
+
 // Creates KDK ssh keypair
 func (c *KdkEnvConfig) CreateKdkSshKeyPair() (err error) {
 
 	if _, err := os.Stat(c.ConfigRootDir()); os.IsNotExist(err) {
-		if err := os.Mkdir(c.ConfigRootDir(), 0700); err != nil {
+		if err := mkdirPolicy(c.ConfigRootDir()); err != nil {
 			log.WithField("error", err).Fatal("Failed to create KDK config directory")
 		}
 	}
 	if _, err := os.Stat(c.KeypairDir()); os.IsNotExist(err) {
-		if err := os.Mkdir(c.KeypairDir(), 0700); err != nil {
+		if err := mkdirPolicy(c.KeypairDir()); err != nil {
 			log.WithField("error", err).Fatal("Failed to create ssh key directory")
 		}
 	}
@@ -333,12 +817,19 @@ func (c *KdkEnvConfig) CreateKdkSshKeyPair() (err error) {
 			log.WithField("error", err).Fatal("Failed to write ssh public key")
 			return err
 		}
+		// the public key follows the permission policy, unlike the private key
+		if err := os.Chmod(c.PublicKeyPath(), FileMode); err != nil {
+			return err
+		}
 		log.Info("Successfully generated ssh key pair.")
 
 	} else {
 		log.Info("KDK ssh key pair exists.")
+		if err := checkKeyPair(c.PrivateKeyPath(), c.PublicKeyPath()); err != nil {
+			return err
+		}
 	}
-	return nil
+	return ensurePrivateKeyMode(c.PrivateKeyPath())
 }
 
 // Returns SSH connection string
@@ -348,47 +839,61 @@ func (c *KdkEnvConfig) SSHConnectionString() string {
 
 // Returns SSH command string
 func (c *KdkEnvConfig) SSHCommandString() string {
-	return fmt.Sprintf("ssh %s -A -p %s -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null",
-		c.SSHConnectionString(), c.ConfigFile.AppConfig.Port, c.PrivateKeyPath())
+	return commandString(c.sshArgs())
 }
 
 // Returns SCP command string
 func (c *KdkEnvConfig) SCPCommandString() string {
-	return fmt.Sprintf("scp -P %s -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null",
-		c.ConfigFile.AppConfig.Port, c.PrivateKeyPath())
+	return commandString(c.scpArgs())
 }
 
 // SCP's a file into the KDK container
 func (c *KdkEnvConfig) SCPTo(hostPath, kdkPath string) error {
-	commandString := fmt.Sprintf("%s %s %s:%s", c.SCPCommandString(), hostPath, c.SSHConnectionString(), kdkPath)
-	log.Infof("executing scp command: %s", commandString)
-	commandMap := strings.Split(commandString, " ")
-	if err := sh.Command(commandMap[0], commandMap[1:]).SetStdin(os.Stdin).Run(); err != nil {
+	args := append(c.scpArgs(), hostPath, c.SSHConnectionString()+":"+kdkPath)
+	log.Infof("executing scp command: %s", commandString(args))
+	if err := sh.Command(args[0], args[1:]).SetStdin(os.Stdin).Run(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// Executes a command on the KDK container
+// Executes a command on the KDK container.  No pty is allocated (ssh -T), whatever the container Tty setting,
+// so output may be piped and parsed.
 func (c *KdkEnvConfig) Exec(command string) error {
-	commandString := fmt.Sprintf("%s %s", c.SSHCommandString(), command)
-	log.Infof("executing ssh command: %s", commandString)
-	commandMap := strings.Split(commandString, " ")
-	return sh.Command(commandMap[0], commandMap[1:]).SetStdin(os.Stdin).Run()
+	args := append(c.sshArgs(), "-T")
+	args = append(args, strings.Split(command, " ")...)
+	log.Infof("executing ssh command: %s", commandString(args))
+	return sh.Command(args[0], args[1:]).SetStdin(os.Stdin).Run()
+}
+
+// Find the KDK container, running or not.  Returns nil if no container exists.
+func (c *KdkEnvConfig) findContainer() (*types.Container, error) {
+	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+	for _, container := range containers {
+		for _, name := range container.Names {
+			if name == "/"+c.ContainerName() {
+				return &container, nil
+			}
+		}
+	}
+	return nil, nil
 }
 
 // Checks that KDK container is running
-func (c *KdkEnvConfig) IsRunning() bool {
+func (c *KdkEnvConfig) IsRunning() (bool, error) {
 	kdkRunning := false
 
 	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{All: true})
 	if err != nil {
-		log.WithField("error", err).Fatal("Failed to list docker containers")
+		return false, wrapDockerError(err)
 	}
 
 	for _, container := range containers {
 		for _, name := range container.Names {
-			if name == "/"+c.ConfigFile.AppConfig.Name {
+			if name == "/"+c.ContainerName() {
 				if container.State == "running" {
 					kdkRunning = true
 					break
@@ -396,15 +901,40 @@ func (c *KdkEnvConfig) IsRunning() bool {
 			}
 		}
 	}
-	return kdkRunning
+	return kdkRunning, nil
 }
 
 // If KDK container is not running, start it and provision KDK user.
-func (c *KdkEnvConfig) Start() {
-	if !c.IsRunning() {
+func (c *KdkEnvConfig) Start() error {
+	running, err := c.IsRunning()
+	if err != nil {
+		return err
+	}
+	if !running {
 		log.Info("KDK is not currently running.  Starting...")
-		Pull(c, false)
-		Up(*c)
-		Provision(*c)
+		if err := Pull(c, false); err != nil {
+			return err
+		}
+		if err := c.FetchKey(); err != nil {
+			return err
+		}
+		if err := c.StartSidecars(); err != nil {
+			return err
+		}
+		if err := Up(c); err != nil {
+			return err
+		}
+		if err := c.Prepare(); err != nil {
+			return err
+		}
+		if c.ConfigFile.AppConfig.RemoteSync != nil {
+			if err := c.SyncMounts(false); err != nil {
+				log.WithField("error", err).Warn("Failed to sync host directories to the KDK")
+			}
+			log.Info("Run `kdk sync` to keep host directories in sync with the KDK")
+		}
+		return nil
 	}
+	c.warnIfNeedsRecreate()
+	return nil
 }
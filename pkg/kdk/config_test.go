@@ -0,0 +1,137 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Create a temp home dir for a KdkEnvConfig, removed by the returned func
+func tempHomeEnv(t *testing.T, name string) (*KdkEnvConfig, func()) {
+	dir, err := ioutil.TempDir("", "kdk-home")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	env := &KdkEnvConfig{HomeDir: dir}
+	env.ConfigFile.AppConfig.Name = name
+	return env, func() { os.RemoveAll(dir) }
+}
+
+// Write a config.yaml with the given AppConfig yaml to the config dir of env
+func writeTestConfig(t *testing.T, env *KdkEnvConfig, appConfig string) {
+	if err := os.MkdirAll(env.ConfigDir(), 0700); err != nil {
+		t.Log("Failed to create config dir.", err)
+		t.FailNow()
+	}
+	data := "AppConfig:\n  Name: " + env.ConfigFile.AppConfig.Name + "\n  Port: \"2022\"\n  ImageRepository: ciscosso/kdk\n  ImageTag: debian-latest\n" + appConfig
+	if err := ioutil.WriteFile(filepath.Join(env.ConfigDir(), "config.yaml"), []byte(data), 0600); err != nil {
+		t.Log("Failed to write config.yaml.", err)
+		t.FailNow()
+	}
+}
+
+func TestLoadKdkConfigBootstrap(t *testing.T) {
+
+	tests := []struct {
+		appConfig string
+		bootstrap bool
+	}{
+		{"", true}, // written before AppConfig.Bootstrap existed
+		{"  Bootstrap: true\n", true},
+		{"  Bootstrap: false\n", false},
+	}
+	for _, test := range tests {
+		env, cleanup := tempHomeEnv(t, "kdk")
+		writeTestConfig(t, env, test.appConfig)
+		err := env.LoadKdkConfig()
+		cleanup()
+		if err != nil {
+			t.Logf("LoadKdkConfig failed for [%q]. %v", test.appConfig, err)
+			t.FailNow()
+		}
+		if env.bootstrap() != test.bootstrap {
+			t.Logf("Bootstrap of [%q] is %v, expected %v", test.appConfig, env.bootstrap(), test.bootstrap)
+			t.FailNow()
+		}
+	}
+}
+
+func TestConfigPathsHomeDir(t *testing.T) {
+
+	env, cleanup := tempHomeEnv(t, "dev")
+	defer cleanup()
+
+	home := env.HomeDir
+	for _, test := range []struct {
+		name, path, expected string
+	}{
+		{"Home", env.Home(), home},
+		{"ConfigRootDir", env.ConfigRootDir(), filepath.Join(home, ".kdk")},
+		{"ConfigDir", env.ConfigDir(), filepath.Join(home, ".kdk", "dev")},
+		{"ConfigPath", env.ConfigPath(), filepath.Join(home, ".kdk", "dev", "config.yaml")},
+		{"KeypairDir", env.KeypairDir(), filepath.Join(home, ".kdk", "ssh")},
+		{"PrivateKeyPath", env.PrivateKeyPath(), filepath.Join(home, ".kdk", "ssh", "id_rsa")},
+		{"PublicKeyPath", env.PublicKeyPath(), filepath.Join(home, ".kdk", "ssh", "id_rsa.pub")},
+	} {
+		if test.path != test.expected {
+			t.Logf("%s is [%s], expected [%s] under HomeDir", test.name, test.path, test.expected)
+			t.FailNow()
+		}
+	}
+}
+
+func TestCreateKdkSshKeyPairHomeDir(t *testing.T) {
+
+	env, cleanup := tempHomeEnv(t, "kdk")
+	defer cleanup()
+
+	if err := env.CreateKdkSshKeyPair(); err != nil {
+		t.Log("CreateKdkSshKeyPair failed.", err)
+		t.FailNow()
+	}
+	for _, path := range []string{env.PrivateKeyPath(), env.PublicKeyPath()} {
+		if _, err := os.Stat(path); err != nil {
+			t.Logf("CreateKdkSshKeyPair did not write %s. %v", path, err)
+			t.FailNow()
+		}
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(env.PrivateKeyPath())
+		if err != nil || info.Mode().Perm() != 0600 {
+			t.Logf("Expected private key mode 0600. %v", err)
+			t.FailNow()
+		}
+	}
+
+	// the existing keypair is checked and kept
+	privateKey, err := ioutil.ReadFile(env.PrivateKeyPath())
+	if err != nil {
+		t.Log("Failed to read private key.", err)
+		t.FailNow()
+	}
+	if err := env.CreateKdkSshKeyPair(); err != nil {
+		t.Log("CreateKdkSshKeyPair rejected its own keypair.", err)
+		t.FailNow()
+	}
+	if again, err := ioutil.ReadFile(env.PrivateKeyPath()); err != nil || string(again) != string(privateKey) {
+		t.Log("CreateKdkSshKeyPair replaced an existing keypair.", err)
+		t.FailNow()
+	}
+}
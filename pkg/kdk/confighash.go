@@ -0,0 +1,97 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Container label with the hashes of the config.yaml sections the container was created from
+const configHashLabel = "kdk.config-hash"
+
+// config.yaml sections passed to the container create, in label order.  AppConfig is left out:
+// `kdk init` writes its container settings into these sections, and the rest (e.g. Hooks, Locked,
+// RemoteSync.Interval) only affects kdk itself, so editing it or `kdk config upgrade` needs no recreate.
+var configSections = []string{"ContainerConfig", "HostConfig"}
+
+// Hashes of the config.yaml sections, as "ContainerConfig=<hash>,HostConfig=<hash>"
+func (c *KdkEnvConfig) configHash() (string, error) {
+	sections := map[string]interface{}{
+		"ContainerConfig": c.ConfigFile.ContainerConfig,
+		"HostConfig":      c.ConfigFile.HostConfig,
+	}
+	var hashes []string
+	for _, section := range configSections {
+		data, err := json.Marshal(sections[section])
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(data)
+		hashes = append(hashes, fmt.Sprintf("%s=%x", section, sum[:6]))
+	}
+	return strings.Join(hashes, ","), nil
+}
+
+// Whether config.yaml changed since the KDK container was created, so that a recreate is required
+// to apply it.  Returns the changed sections.  Containers created before the kdk.config-hash
+// label, or not created at all, never need a recreate.
+func (c *KdkEnvConfig) NeedsRecreate() (bool, []string, error) {
+	container, err := c.findContainer()
+	if err != nil || container == nil {
+		return false, nil, err
+	}
+	created, ok := container.Labels[configHashLabel]
+	if !ok {
+		return false, nil, nil
+	}
+	current, err := c.configHash()
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Compared by section name, since labels of older containers also hash AppConfig
+	createdHashes := parseConfigHash(created)
+	currentHashes := parseConfigHash(current)
+	var changed []string
+	for _, section := range configSections {
+		if createdHashes[section] != currentHashes[section] {
+			changed = append(changed, section)
+		}
+	}
+	return len(changed) > 0, changed, nil
+}
+
+// Parse a kdk.config-hash label into its hashes by section
+func parseConfigHash(label string) map[string]string {
+	hashes := map[string]string{}
+	for _, entry := range strings.Split(label, ",") {
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			hashes[parts[0]] = parts[1]
+		}
+	}
+	return hashes
+}
+
+// Warn when config.yaml changed since the KDK container was created
+func (c *KdkEnvConfig) warnIfNeedsRecreate() {
+	if needsRecreate, changed, err := c.NeedsRecreate(); err == nil && needsRecreate {
+		log.Warnf("KDK config changed (%s) since the container was created.  Run `kdk recreate` to apply", strings.Join(changed, ", "))
+	}
+}
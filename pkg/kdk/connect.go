@@ -0,0 +1,212 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Opens an interactive ssh session to the KDK container using the KDK keypair, without an external ssh binary
+func (c *KdkEnvConfig) Connect() error {
+	client, err := c.dialSSH()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// Forward the local ssh-agent, equivalent of `ssh -A`
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if err := agent.ForwardToRemote(client, socket); err != nil {
+			log.WithField("error", err).Warn("Failed to forward ssh-agent")
+		} else if err := agent.RequestAgentForwarding(session); err != nil {
+			log.WithField("error", err).Warn("Failed to request ssh-agent forwarding")
+		}
+	}
+
+	// Put the local terminal into raw mode so that keystrokes pass straight through to the remote shell.
+	//   The deferred restore also runs when the remote closes abruptly, or on panic.
+	width, height := 80, 24
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		state, err := terminal.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		defer terminal.Restore(fd, state)
+		if w, h, err := terminal.GetSize(fd); err == nil {
+			width, height = w, h
+		}
+	}
+
+	term := os.Getenv("TERM")
+	if term == "" {
+		term = "xterm-256color"
+	}
+	modes := gossh.TerminalModes{
+		gossh.ECHO:          1,
+		gossh.TTY_OP_ISPEED: 14400,
+		gossh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, height, width, modes); err != nil {
+		return err
+	}
+
+	// Stdin is copied through the detach key filter.  Detaching closes the ssh session, not the container.
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+	if err := session.Shell(); err != nil {
+		return err
+	}
+	detached := make(chan struct{})
+	go func() {
+		if err := copyWithDetach(stdin, os.Stdin); err == errDetached {
+			close(detached)
+			session.Close()
+		}
+		stdin.Close()
+	}()
+
+	err = session.Wait()
+	select {
+	case <-detached:
+		log.Info("Detached from KDK session.  The KDK container is still running")
+		return nil
+	default:
+		return err
+	}
+}
+
+// Returned by copyWithDetach when the detach key sequence is read
+var errDetached = errors.New("detached")
+
+// Detach key sequence, as the docker default: Ctrl-P Ctrl-Q
+var detachKeys = []byte{0x10, 0x11}
+
+// Copy src to dst until src ends, or the detach key sequence is read.  A partial sequence
+// followed by other input is passed through.
+func copyWithDetach(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 1024)
+	matched := 0
+	for {
+		n, readErr := src.Read(buf)
+		out := make([]byte, 0, n+len(detachKeys))
+		for _, b := range buf[:n] {
+			if b == detachKeys[matched] {
+				if matched++; matched == len(detachKeys) {
+					dst.Write(out)
+					return errDetached
+				}
+				continue
+			}
+			out = append(out, detachKeys[:matched]...)
+			matched = 0
+			if b == detachKeys[0] {
+				matched = 1
+				continue
+			}
+			out = append(out, b)
+		}
+		if readErr != nil {
+			out = append(out, detachKeys[:matched]...)
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if readErr == io.EOF {
+			return nil
+		} else if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// Runs a non-interactive command on the KDK container, returning its combined output
+func (c *KdkEnvConfig) Output(command string) (string, error) {
+	client, err := c.dialSSH()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	out, err := session.CombinedOutput(command)
+	return string(out), err
+}
+
+// Dials the KDK container ssh port as the KDK user
+func (c *KdkEnvConfig) dialSSH() (*gossh.Client, error) {
+	auth, err := c.sshAuthMethod()
+	if err != nil {
+		return nil, err
+	}
+	config := &gossh.ClientConfig{
+		User: c.User(),
+		Auth: []gossh.AuthMethod{auth},
+		// Equivalent of `-o StrictHostKeyChecking=no`.  The container host key changes on every recreate.
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	}
+
+	if c.ConfigFile.AppConfig.SocketOnly {
+		conn, err := c.dialSshd()
+		if err != nil {
+			return nil, err
+		}
+		sshConn, chans, reqs, err := gossh.NewClientConn(conn, c.ContainerName(), config)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return gossh.NewClient(sshConn, chans, reqs), nil
+	}
+	client, err := gossh.Dial("tcp", net.JoinHostPort("localhost", c.ConfigFile.AppConfig.Port), config)
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+	return client, nil
+}
+
+// Authenticate with the signers of the configured KeyProvider
+func (c *KdkEnvConfig) sshAuthMethod() (gossh.AuthMethod, error) {
+	provider, err := c.keyProvider()
+	if err != nil {
+		return nil, err
+	}
+	return gossh.PublicKeysCallback(func() ([]gossh.Signer, error) {
+		return provider.Signers(c)
+	}), nil
+}
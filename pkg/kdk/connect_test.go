@@ -0,0 +1,66 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// Reader returning each chunk from a separate Read
+type chunkReader struct {
+	chunks []string
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestCopyWithDetach(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected string
+		detached bool
+	}{
+		{"plain input", []string{"abc", "def"}, "abcdef", false},
+		{"detach", []string{"ab\x10\x11cd"}, "ab", true},
+		{"detach across reads", []string{"a\x10", "\x11b"}, "a", true},
+		{"partial then other input", []string{"\x10x"}, "\x10x", false},
+		{"partial across reads then other input", []string{"a\x10", "b"}, "a\x10b", false},
+		{"repeated prefix", []string{"\x10\x10\x11"}, "\x10", true},
+		{"second key alone", []string{"\x11a"}, "\x11a", false},
+		{"partial at end of input", []string{"a\x10"}, "a\x10", false},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := copyWithDetach(&out, &chunkReader{chunks: test.chunks})
+		if detached := err == errDetached; detached != test.detached || (!detached && err != nil) {
+			t.Logf("copyWithDetach %s returned [%v], expected detached %v", test.name, err, test.detached)
+			t.FailNow()
+		}
+		if out.String() != test.expected {
+			t.Logf("copyWithDetach %s wrote %q, expected %q", test.name, out.String(), test.expected)
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Converge the KDK container to config.yaml with the fewest actions: create it if missing, pull
+// the configured image if missing (or always, with pull), recreate it when the image or config
+// changed since it was created, and start it if stopped.  Named volumes are kept across the
+// recreate.  Returns the actions taken, none when the KDK was already converged.
+func (c *KdkEnvConfig) Apply(pull bool) (actions []string, err error) {
+	if c.ConfigFile.HostConfig == nil {
+		return nil, fmt.Errorf("%w: run `kdk init` first", ErrConfigNotFound)
+	}
+	container, err := c.findContainer()
+	if err != nil {
+		return nil, err
+	}
+	if container == nil {
+		if err := c.Start(); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("created container [%s]", c.ContainerName())}, nil
+	}
+
+	if pull || !hasKdkImageWithTag(c, c.ImageTag()) || !hasImagePlatform(c, c.ImageCoordinates()) {
+		if err := Pull(c, pull); err != nil {
+			return nil, err
+		}
+		actions = append(actions, fmt.Sprintf("pulled image [%s]", c.ImageCoordinates()))
+	}
+
+	// Fields changed since create, which only a recreate applies
+	var changed []string
+	image, _, err := c.DockerClient.ImageInspectWithRaw(c.Ctx, c.ImageCoordinates())
+	if err != nil {
+		return nil, wrapImageError(err)
+	}
+	if image.ID != container.ImageID {
+		changed = append(changed, "image")
+	}
+	_, sections, err := c.NeedsRecreate()
+	if err != nil {
+		return nil, err
+	}
+	changed = append(changed, sections...)
+
+	if len(changed) > 0 {
+		log.Infof("KDK %s changed since the container was created.  Recreating", strings.Join(changed, ", "))
+		if err := c.RecreateKdk(true); err != nil {
+			return nil, err
+		}
+		return append(actions, fmt.Sprintf("recreated container [%s] (%s changed)", c.ContainerName(), strings.Join(changed, ", "))), nil
+	}
+
+	if container.State != "running" {
+		if err := containerStart(*c, container.ID); err != nil {
+			return nil, err
+		}
+		if err := c.Prepare(); err != nil {
+			return nil, err
+		}
+		actions = append(actions, fmt.Sprintf("started container [%s]", c.ContainerName()))
+	}
+	return actions, nil
+}
@@ -0,0 +1,70 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Number of CPUs available to containers.  Docker Desktop runs the daemon in a VM, so prefer the
+// daemon's count over the host's.
+func (c *KdkEnvConfig) daemonCPUs() int {
+	if c.DockerClient != nil {
+		info, err := c.daemonInfo()
+		if err == nil && info.NCPU > 0 {
+			return info.NCPU
+		}
+		log.WithField("error", err).Debug("Failed to get docker daemon CPU count.  Using the host CPU count")
+	}
+	return runtime.NumCPU()
+}
+
+// A cpuset is a comma separated list of CPUs or CPU ranges, e.g. "0-3" or "0,2,4-5".  When ncpu is
+// known (greater than zero) every CPU must be below it.
+func validateCpuset(cpuset string, ncpu int) error {
+	if cpuset == "" {
+		return nil
+	}
+	for _, item := range strings.Split(cpuset, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil || low < 0 {
+			return fmt.Errorf("invalid CpusetCpus [%s]: [%s] is not a CPU or CPU range", cpuset, item)
+		}
+		high := low
+		if len(bounds) == 2 {
+			if high, err = strconv.Atoi(bounds[1]); err != nil || high < low {
+				return fmt.Errorf("invalid CpusetCpus [%s]: [%s] is not a CPU or CPU range", cpuset, item)
+			}
+		}
+		if ncpu > 0 && high >= ncpu {
+			return fmt.Errorf("invalid CpusetCpus [%s]: CPU [%d] is not available, the docker daemon has CPUs 0-%d", cpuset, high, ncpu-1)
+		}
+	}
+	return nil
+}
+
+// CPU shares are a relative weight (docker default 1024).  Zero uses the docker default
+func validateCpuShares(shares int64) error {
+	if shares != 0 && (shares < 2 || shares > 262144) {
+		return fmt.Errorf("invalid CpuShares [%d]: must be between 2 and 262144", shares)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// One screen summary of a KDK environment, from its config and the container inspect
+type KdkDescription struct {
+	KdkStatus
+	Port           string   // ssh port on the host
+	Mounts         []string // mounts and binds of the config
+	KeyFingerprint string   // SHA256 fingerprint of the KDK ssh public key, which the container authorizes
+	SshCommand     string
+}
+
+// Returns the description of the KDK environment
+func (c *KdkEnvConfig) Description() (*KdkDescription, error) {
+	status, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+	// there may be no public key file, e.g. with an agent key provider
+	fingerprint, err := c.Fingerprint()
+	if errors.Is(err, os.ErrNotExist) {
+		fingerprint = ""
+	} else if err != nil {
+		return nil, err
+	}
+	mounts, _, _ := c.configSets()
+	return &KdkDescription{
+		KdkStatus:      *status,
+		Port:           c.ConfigFile.AppConfig.Port,
+		Mounts:         mounts,
+		KeyFingerprint: fingerprint,
+		SshCommand:     c.SSHCommandString(),
+	}, nil
+}
+
+// Returns the description of the KDK environment, formatted for display
+func (c *KdkEnvConfig) Describe() (string, error) {
+	description, err := c.Description()
+	if err != nil {
+		return "", err
+	}
+	return description.String(), nil
+}
+
+func (d *KdkDescription) String() string {
+	fingerprint := d.KeyFingerprint
+	if fingerprint == "" {
+		fingerprint = "none"
+	}
+	out := d.KdkStatus.String()
+	out += fmt.Sprintf("SSH Port:  %s\nKey:       %s\nSSH:       %s\n", d.Port, fingerprint, d.SshCommand)
+	out += fmt.Sprintf("Mounts:    %s\n", strings.Join(d.Mounts, "\n           "))
+	return out
+}
@@ -33,7 +33,7 @@ func Destroy(cfg KdkEnvConfig, force bool) error {
 	}
 	for _, container := range containers {
 		for _, name := range container.Names {
-			if name == "/"+cfg.ConfigFile.AppConfig.Name {
+			if name == "/"+cfg.ContainerName() {
 				containerIds = append(containerIds, container.ID)
 				break
 			}
@@ -41,9 +41,10 @@ func Destroy(cfg KdkEnvConfig, force bool) error {
 	}
 	if len(containerIds) > 0 {
 		log.Info("Destroying KDK container(s)...")
+		hooksRun := false
 		for _, containerId := range containerIds {
 			if !force {
-				fmt.Printf("Delete KDK container [%s][%v]\n", cfg.ConfigFile.AppConfig.Name, containerId[:8])
+				fmt.Printf("Delete KDK container [%s][%v]\n", cfg.ContainerName(), containerId[:8])
 				prmpt := prompt.Prompt{
 					Text:     "Continue? [y/n] ",
 					Loop:     true,
@@ -54,13 +55,25 @@ func Destroy(cfg KdkEnvConfig, force bool) error {
 					return nil
 				}
 			}
+			if !hooksRun {
+				if err := cfg.runHooks("pre-stop"); err != nil {
+					log.WithField("error", err).Error("KDK container deletion canceled.")
+					return err
+				}
+				hooksRun = true
+			}
+			cfg.stopContainer(containerId)
 			if err := cfg.DockerClient.ContainerRemove(cfg.Ctx, containerId, types.ContainerRemoveOptions{Force: true}); err != nil {
 				log.WithField("error", err).Fatal("Failed to remove KDK container")
 			}
 		}
+		cfg.removeStatusFile()
 		log.Info("KDK destroy complete.")
 	} else {
 		log.Info("No KDK containers found. Nothing to destroy...")
 	}
+	if err := cfg.RemoveSidecars(); err != nil {
+		log.WithField("error", err).Fatal("Failed to remove KDK sidecars")
+	}
 	return nil
 }
@@ -0,0 +1,87 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Line based diff of a and b via their longest common subsequence.  Lines are prefixed
+// with "+ " when added in b, "- " when removed from a, and "  " when unchanged.
+func lineDiff(a, b string) []string {
+	aLines := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	bLines := strings.Split(strings.TrimRight(b, "\n"), "\n")
+
+	// lcs[i][j] is the LCS length of aLines[i:] and bLines[j:]
+	lcs := make([][]int, len(aLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bLines)+1)
+	}
+	for i := len(aLines) - 1; i >= 0; i-- {
+		for j := len(bLines) - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(aLines) && j < len(bLines) {
+		switch {
+		case aLines[i] == bLines[j]:
+			diff = append(diff, "  "+aLines[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+aLines[i])
+			i++
+		default:
+			diff = append(diff, "+ "+bLines[j])
+			j++
+		}
+	}
+	for ; i < len(aLines); i++ {
+		diff = append(diff, "- "+aLines[i])
+	}
+	for ; j < len(bLines); j++ {
+		diff = append(diff, "+ "+bLines[j])
+	}
+	return diff
+}
+
+// Render the changed lines of a diff, in color when stdout is a terminal.  Returns "" when nothing changed.
+func formatDiff(diff []string) string {
+	color := terminal.IsTerminal(int(os.Stdout.Fd()))
+	var out strings.Builder
+	for _, line := range diff {
+		switch {
+		case strings.HasPrefix(line, "+ ") && color:
+			out.WriteString("\x1b[32m" + line + "\x1b[0m\n")
+		case strings.HasPrefix(line, "- ") && color:
+			out.WriteString("\x1b[31m" + line + "\x1b[0m\n")
+		case strings.HasPrefix(line, "+ "), strings.HasPrefix(line, "- "):
+			out.WriteString(line + "\n")
+		}
+	}
+	return out.String()
+}
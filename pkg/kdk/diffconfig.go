@@ -0,0 +1,95 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Sorted mounts (including binds), env, and port bindings of a KDK environment, one per line
+func (c *KdkEnvConfig) configSets() (mounts, env, ports []string) {
+	if hostConfig := c.ConfigFile.HostConfig; hostConfig != nil {
+		for _, m := range hostConfig.Mounts {
+			line := fmt.Sprintf("%s %s:%s", m.Type, m.Source, m.Target)
+			if m.ReadOnly {
+				line += ":ro"
+			}
+			mounts = append(mounts, line)
+		}
+		for _, bind := range hostConfig.Binds {
+			mounts = append(mounts, "bind "+bind)
+		}
+		for port, bindings := range hostConfig.PortBindings {
+			for _, binding := range bindings {
+				ports = append(ports, fmt.Sprintf("%s:%s->%s", binding.HostIP, binding.HostPort, port))
+			}
+		}
+	}
+	if containerConfig := c.ConfigFile.ContainerConfig; containerConfig != nil {
+		env = append(env, containerConfig.Env...)
+	}
+	sort.Strings(mounts)
+	sort.Strings(env)
+	sort.Strings(ports)
+	return mounts, env, ports
+}
+
+// Diff the config.yaml of KDK environments nameA and nameB.  Changes are grouped by AppConfig,
+// mounts, env, and ports.  Returns "" when the environments are equivalent.
+func (c *KdkEnvConfig) DiffConfigs(nameA, nameB string) (string, error) {
+	envA, err := c.loadEnv(nameA)
+	if err != nil {
+		return "", err
+	}
+	envB, err := c.loadEnv(nameB)
+	if err != nil {
+		return "", err
+	}
+
+	// The name differs by definition
+	appConfigA, appConfigB := envA.ConfigFile.AppConfig, envB.ConfigFile.AppConfig
+	appConfigA.Name, appConfigB.Name = "", ""
+	yamlA, err := yaml.Marshal(appConfigA)
+	if err != nil {
+		return "", err
+	}
+	yamlB, err := yaml.Marshal(appConfigB)
+	if err != nil {
+		return "", err
+	}
+	mountsA, envVarsA, portsA := envA.configSets()
+	mountsB, envVarsB, portsB := envB.configSets()
+
+	sections := []struct {
+		title string
+		a, b  string
+	}{
+		{"AppConfig", string(yamlA), string(yamlB)},
+		{"Mounts", strings.Join(mountsA, "\n"), strings.Join(mountsB, "\n")},
+		{"Env", strings.Join(envVarsA, "\n"), strings.Join(envVarsB, "\n")},
+		{"Ports", strings.Join(portsA, "\n"), strings.Join(portsB, "\n")},
+	}
+	var out strings.Builder
+	for _, section := range sections {
+		if diff := formatDiff(lineDiff(section.a, section.b)); diff != "" {
+			fmt.Fprintf(&out, "%s (- %s, + %s):\n%s", section.title, nameA, nameB, diff)
+		}
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,112 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	log "github.com/sirupsen/logrus"
+)
+
+// Connection timeout of the docker daemon dialer.  Distinct from DockerTimeout, which
+// bounds whole requests (including streamed image pulls).
+const dockerDialTimeout = 30 * time.Second
+
+// Duration from environment variable env, or def if unset or unparseable
+func EnvDuration(env string, def time.Duration) time.Duration {
+	value := os.Getenv(env)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.WithField("error", err).Warnf("Ignoring invalid %s", env)
+		return def
+	}
+	return d
+}
+
+// Apply the configured request timeout and TCP keepalive to a docker client.  A zero
+// DockerTimeout leaves requests unbounded, so multi-gigabyte pulls and followed logs are
+// never aborted.  Keepalive applies to tcp daemons only; unix sockets, named pipes and
+// ssh hosts keep their own dialers.
+func (c *KdkEnvConfig) configureDockerClient(dockerClient *client.Client) error {
+	if c.DockerTimeout > 0 {
+		if err := client.WithTimeout(c.DockerTimeout)(dockerClient); err != nil {
+			return err
+		}
+	}
+	if c.DockerKeepAlive > 0 && strings.HasPrefix(dockerClient.DaemonHost(), "tcp://") {
+		dialer := &net.Dialer{Timeout: dockerDialTimeout, KeepAlive: c.DockerKeepAlive}
+		if err := client.WithDialContext(dialer.DialContext)(dockerClient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Whether err is a docker request that timed out, rather than a daemon that refused
+// the connection
+func isDockerTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Docker daemon info, by client, fetched once per process since several checks need it
+var (
+	daemonInfoMu    sync.Mutex
+	daemonInfoCache = map[*client.Client]types.Info{}
+)
+
+// The docker daemon info, cached for the life of the docker client
+func (c *KdkEnvConfig) daemonInfo() (types.Info, error) {
+	daemonInfoMu.Lock()
+	defer daemonInfoMu.Unlock()
+	if info, ok := daemonInfoCache[c.DockerClient]; ok {
+		return info, nil
+	}
+	info, err := c.DockerClient.Info(c.Ctx)
+	if err != nil {
+		return info, wrapDockerError(err)
+	}
+	daemonInfoCache[c.DockerClient] = info
+	return info, nil
+}
+
+// Whether the docker daemon reports the security option name, e.g. rootless, userns, or selinux
+func (c *KdkEnvConfig) hasSecurityOption(name string) bool {
+	info, err := c.daemonInfo()
+	if err != nil {
+		log.WithField("error", err).Debug("Failed to get docker daemon info")
+		return false
+	}
+	for _, opt := range info.SecurityOptions {
+		if strings.Contains(opt, "name="+name) {
+			return true
+		}
+	}
+	return false
+}
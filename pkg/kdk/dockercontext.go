@@ -0,0 +1,111 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/client"
+	"github.com/mitchellh/go-homedir"
+)
+
+// The docker endpoint of a docker cli context (~/.docker/contexts/meta/<sha256 of name>/meta.json)
+type dockerContextMeta struct {
+	Name      string
+	Endpoints map[string]struct {
+		Host          string
+		SkipTLSVerify bool
+	}
+}
+
+// docker cli config directory ($DOCKER_CONFIG or ~/.docker)
+func dockerConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".docker")
+}
+
+// Resolve the docker context to use, as the docker cli does: an explicit name, then DOCKER_CONTEXT,
+// then the currentContext of ~/.docker/config.json.  DOCKER_HOST takes precedence over all but an
+// explicit name. Returns "" for the default context.
+func resolveDockerContext(name string) string {
+	if name == "" {
+		if os.Getenv("DOCKER_HOST") != "" {
+			return ""
+		}
+		name = os.Getenv("DOCKER_CONTEXT")
+	}
+	if name == "" {
+		var config struct{ CurrentContext string }
+		if data, err := ioutil.ReadFile(filepath.Join(dockerConfigDir(), "config.json")); err == nil {
+			json.Unmarshal(data, &config)
+		}
+		name = config.CurrentContext
+	}
+	if name == "default" {
+		return ""
+	}
+	return name
+}
+
+// Build docker client options for the named docker cli context
+func dockerContextOpts(name string) ([]client.Opt, error) {
+	sum := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(sum[:])
+	data, err := ioutil.ReadFile(filepath.Join(dockerConfigDir(), "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return nil, fmt.Errorf("docker context [%s] not found: %v", name, err)
+	}
+	var meta dockerContextMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid docker context [%s]: %v", name, err)
+	}
+	endpoint, ok := meta.Endpoints["docker"]
+	if !ok || endpoint.Host == "" {
+		return nil, fmt.Errorf("docker context [%s] has no docker endpoint", name)
+	}
+
+	opts := []client.Opt{client.FromEnv, client.WithHost(endpoint.Host)}
+
+	// ssh:// endpoints tunnel through `docker system dial-stdio` on the remote host
+	helper, err := connhelper.GetConnectionHelper(endpoint.Host)
+	if err != nil {
+		return nil, err
+	}
+	if helper != nil {
+		opts = append(opts,
+			client.WithHTTPClient(&http.Client{Transport: &http.Transport{DialContext: helper.Dialer}}),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		)
+	}
+
+	tlsDir := filepath.Join(dockerConfigDir(), "contexts", "tls", id, "docker")
+	if _, err := os.Stat(filepath.Join(tlsDir, "ca.pem")); err == nil {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(tlsDir, "ca.pem"), filepath.Join(tlsDir, "cert.pem"), filepath.Join(tlsDir, "key.pem")))
+	}
+	return opts, nil
+}
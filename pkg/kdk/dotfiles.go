@@ -0,0 +1,43 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Host dotfiles, relative to the home directory, mounted when AppConfig.MountCommonDotfiles is set
+var commonDotfiles = []string{".gitconfig", ".aws", ".kube"}
+
+// Read-only mounts of the common dotfiles that exist on the host
+func (c *KdkEnvConfig) commonDotfileMounts() []mount.Mount {
+	var mounts []mount.Mount
+	for _, dotfile := range commonDotfiles {
+		source := filepath.Join(c.Home(), dotfile)
+		if _, err := os.Stat(source); err != nil {
+			log.Debugf("Skipping mount of missing host dotfile %s", source)
+			continue
+		}
+		target := path.Join(c.ContainerHome(), dotfile)
+		log.Infof("Mounting host dotfile %s read-only at %s", source, target)
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target, ReadOnly: true})
+	}
+	return mounts
+}
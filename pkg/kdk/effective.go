@@ -0,0 +1,105 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// Returns the configuration as it is used to create the KDK container: a copy of config.yaml plus
+// the settings added at create time (labels, remote sync volumes, mount consistency, proxy and
+// EnvFile environment).  Flags and environment variables are not merged in.  The stored config is
+// not modified.
+func (c *KdkEnvConfig) EffectiveConfig() (configFile, error) {
+	var effective configFile
+	if c.ConfigFile.ContainerConfig == nil || c.ConfigFile.HostConfig == nil {
+		return effective, errors.New("KDK config is incomplete: run `kdk init`")
+	}
+
+	// deep copy, so that create time settings don't leak into config.yaml
+	data, err := json.Marshal(&c.ConfigFile)
+	if err != nil {
+		return effective, err
+	}
+	if err := json.Unmarshal(data, &effective); err != nil {
+		return effective, err
+	}
+
+	// Ownership, version, and age labels, for cleanup policies (ReapKdk) and ReconcileLabels, and the
+	//   config hash for NeedsRecreate
+	if effective.ContainerConfig.Labels == nil {
+		effective.ContainerConfig.Labels = map[string]string{}
+	}
+	if effective.ContainerConfig.Labels[configHashLabel], err = c.configHash(); err != nil {
+		return effective, err
+	}
+	effective.ContainerConfig.Labels[versionLabel] = Version
+	effective.ContainerConfig.Labels[userLabel] = c.User()
+	effective.ContainerConfig.Labels[createdLabel] = time.Now().UTC().Format(time.RFC3339)
+
+	// Volumes synced from host directories replace their bind mounts, for remote daemons
+	c.applyRemoteSync(effective.HostConfig)
+
+	// A per-platform image tag resolves on the host creating the container, so that a shared
+	//   config.yaml selects the image variant of each host
+	if len(effective.AppConfig.ImageTags) > 0 && effective.AppConfig.ImageTag == "" {
+		effective.ContainerConfig.Image = c.ImageCoordinates()
+	}
+
+	// An in-memory KDK has its public key copied in rather than mounted
+	c.dropBootstrapKeyMount(effective.ContainerConfig, effective.HostConfig)
+
+	// Missing bind mount sources only warn at load, and fail here at create
+	if err := c.checkMountSources(effective.HostConfig); err != nil {
+		return effective, err
+	}
+
+	// sshd config drop-in, checked at create rather than load so that a moved file doesn't fail
+	//   every command
+	if sshdConfig := effective.AppConfig.SshdConfig; sshdConfig != "" && c.localDaemon() {
+		if err := validateSshdConfig(sshdConfig); err != nil {
+			return effective, err
+		}
+	}
+
+	// Bind mount consistency (macOS only)
+	applyMountProfile(effective.HostConfig, effective.AppConfig.MountProfile)
+
+	// Proxy environment.  Explicit config.yaml entries take precedence
+	effective.ContainerConfig.Env = mergeEnv(effective.ContainerConfig.Env, effective.AppConfig.Proxy.env())
+
+	// Environment file, read at create time so that edits apply on the next recreate
+	if envFile := effective.AppConfig.EnvFile; envFile != "" {
+		env, err := parseEnvFile(envFile)
+		if err != nil {
+			return effective, err
+		}
+		effective.ContainerConfig.Env = mergeEnv(effective.ContainerConfig.Env, env)
+	}
+	return effective, nil
+}
+
+// Returns the effective configuration as YAML
+func (c *KdkEnvConfig) EffectiveConfigYAML() ([]byte, error) {
+	effective, err := c.EffectiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&effective)
+}
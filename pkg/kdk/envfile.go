@@ -0,0 +1,61 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse a .env file of KEY=VALUE lines.  Blank lines and # comments are ignored, and values are kept verbatim.
+func parseEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EnvFile [%s]: %v", path, err)
+	}
+	defer f.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || kv[0] == "" || strings.ContainsAny(kv[0], " \t") {
+			return nil, fmt.Errorf("invalid EnvFile [%s] line %d: expected KEY=VALUE", path, lineNum)
+		}
+		env = append(env, line)
+	}
+	return env, scanner.Err()
+}
+
+// Merge env file entries into env.  Entries already in env (i.e. config.yaml) take precedence.
+func mergeEnv(env []string, envFile []string) []string {
+	keys := map[string]bool{}
+	for _, kv := range env {
+		keys[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+	for _, kv := range envFile {
+		if key := strings.SplitN(kv, "=", 2)[0]; !keys[key] {
+			env = append(env, kv)
+			keys[key] = true
+		}
+	}
+	return env
+}
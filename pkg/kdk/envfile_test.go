@@ -0,0 +1,84 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Write content to a .env file in a temp dir, returning its path and a cleanup func
+func writeTestEnvFile(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "kdk-envfile")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	path := filepath.Join(dir, ".env")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Log("Failed to write env file.", err)
+		t.FailNow()
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestParseEnvFile(t *testing.T) {
+
+	path, cleanup := writeTestEnvFile(t, "# comment\n\nFOO=bar\n  BAZ=a=b # not a comment\n\t# indented comment\nEMPTY=\n")
+	defer cleanup()
+
+	env, err := parseEnvFile(path)
+	if err != nil {
+		t.Log("parseEnvFile rejected a valid env file.", err)
+		t.FailNow()
+	}
+	expected := []string{"FOO=bar", "BAZ=a=b # not a comment", "EMPTY="}
+	if strings.Join(env, "\n") != strings.Join(expected, "\n") {
+		t.Logf("parseEnvFile returned %q, expected %q", env, expected)
+		t.FailNow()
+	}
+}
+
+func TestParseEnvFileMalformed(t *testing.T) {
+
+	for _, test := range []struct {
+		content string
+		line    string
+	}{
+		{"FOO=bar\nNOVALUE\n", "line 2"},
+		{"# comment\n\nKEY =v\n", "line 3"},
+		{"=value\n", "line 1"},
+		{"FOO=bar\nMY KEY=v\n", "line 2"},
+	} {
+		path, cleanup := writeTestEnvFile(t, test.content)
+		_, err := parseEnvFile(path)
+		cleanup()
+		if err == nil || !strings.Contains(err.Error(), test.line) {
+			t.Logf("parseEnvFile did not report %s of %q. %v", test.line, test.content, err)
+			t.FailNow()
+		}
+	}
+}
+
+func TestParseEnvFileMissing(t *testing.T) {
+
+	if _, err := parseEnvFile(filepath.Join(os.TempDir(), "kdk-missing", ".env")); err == nil || !strings.Contains(err.Error(), "EnvFile") {
+		t.Log("parseEnvFile did not report a missing env file.", err)
+		t.FailNow()
+	}
+}
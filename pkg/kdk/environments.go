@@ -0,0 +1,109 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+)
+
+// Environments may also be kept in one file, ~/.kdk/environments.yaml, mapping each
+// environment name to its config.yaml content, e.g. to version control them together:
+//
+//	kdk:
+//	  AppConfig: ...
+//	  ContainerConfig: ...
+//	  HostConfig: ...
+//
+// A ~/.kdk/<KDK_NAME>/config.yaml takes precedence over an entry of the same name.
+const environmentsFile = "environments.yaml"
+
+// kdk aggregated environments path (~/.kdk/environments.yaml)
+func (c *KdkEnvConfig) EnvironmentsPath() string {
+	return filepath.Join(c.ConfigRootDir(), environmentsFile)
+}
+
+// The entries of ~/.kdk/environments.yaml, if it exists
+func (c *KdkEnvConfig) readEnvironments() (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(c.EnvironmentsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	environments := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &environments); err != nil {
+		return nil, err
+	}
+	return environments, nil
+}
+
+// Whether this environment is kept in ~/.kdk/environments.yaml rather than its own config.yaml
+func (c *KdkEnvConfig) inEnvironments() bool {
+	if _, err := os.Stat(c.ConfigPath()); !os.IsNotExist(err) {
+		return false
+	}
+	environments, err := c.readEnvironments()
+	if err != nil {
+		return false
+	}
+	_, ok := environments[c.ConfigFile.AppConfig.Name]
+	return ok
+}
+
+// The file the config of this environment is read from and written to
+func (c *KdkEnvConfig) ConfigSource() string {
+	if c.inEnvironments() {
+		return c.EnvironmentsPath()
+	}
+	return c.ConfigPath()
+}
+
+// Read the config.yaml content of this environment, from whichever form it is kept in
+func (c *KdkEnvConfig) readConfig() ([]byte, error) {
+	if !c.inEnvironments() {
+		return ioutil.ReadFile(c.ConfigPath())
+	}
+	environments, err := c.readEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(environments[c.ConfigFile.AppConfig.Name])
+}
+
+// Write the config.yaml content of this environment back to whichever form it is kept in.
+// The other entries of ~/.kdk/environments.yaml are kept, though not their comments.
+func (c *KdkEnvConfig) writeConfig(data []byte) error {
+	if !c.inEnvironments() {
+		return writeFilePolicy(c.ConfigPath(), data)
+	}
+	environments, err := c.readEnvironments()
+	if err != nil {
+		return err
+	}
+	var entry interface{}
+	if err := yaml.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	environments[c.ConfigFile.AppConfig.Name] = entry
+	y, err := yaml.Marshal(environments)
+	if err != nil {
+		return err
+	}
+	return writeFilePolicy(c.EnvironmentsPath(), y)
+}
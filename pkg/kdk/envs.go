@@ -0,0 +1,157 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cisco-sso/kdk/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// List the names of all KDK environments with a config file under ~/.kdk, or an entry in ~/.kdk/environments.yaml
+func (c *KdkEnvConfig) ListKdkConfigs() ([]string, error) {
+	entries, err := ioutil.ReadDir(c.ConfigRootDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(c.ConfigRootDir(), entry.Name(), "config.yaml")); err == nil {
+			found[entry.Name()] = true
+		}
+	}
+	environments, err := c.readEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	for name := range environments {
+		found[name] = true
+	}
+	var names []string
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Load the config of another KDK environment under ~/.kdk
+func (c *KdkEnvConfig) loadEnv(name string) (*KdkEnvConfig, error) {
+	env := &KdkEnvConfig{DockerClient: c.DockerClient, Ctx: c.Ctx, HomeDir: c.HomeDir}
+	env.ConfigFile.AppConfig.Name = name
+	if err := env.LoadKdkConfig(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Map of host ports used by the other KDK environments, to the environment name
+func (c *KdkEnvConfig) otherEnvPorts() map[string]string {
+	ports := map[string]string{}
+	names, err := c.ListKdkConfigs()
+	if err != nil {
+		log.WithField("error", err).Debug("Failed to list KDK environments")
+		return ports
+	}
+	for _, name := range names {
+		if name == c.ConfigFile.AppConfig.Name {
+			continue
+		}
+		env, err := c.loadEnv(name)
+		if err != nil {
+			log.WithField("error", err).Debugf("Failed to load KDK environment [%s]", name)
+			continue
+		}
+		ports[env.ConfigFile.AppConfig.Port] = name
+	}
+	return ports
+}
+
+// Choose a different port if the configured port is already used by another KDK environment
+func (c *KdkEnvConfig) ensureUniquePort() {
+	ports := c.otherEnvPorts()
+	for {
+		name, used := ports[c.ConfigFile.AppConfig.Port]
+		if !used {
+			return
+		}
+		port := strconv.Itoa(utils.GetPort())
+		log.Warnf("Port [%s] is already used by KDK environment [%s].  Using port [%s]", c.ConfigFile.AppConfig.Port, name, port)
+		c.ConfigFile.AppConfig.Port = port
+	}
+}
+
+// kdk current environment path (~/.kdk/current)
+func (c *KdkEnvConfig) currentPath() string {
+	return filepath.Join(c.ConfigRootDir(), "current")
+}
+
+// Whether a KDK environment config exists under ~/.kdk, or in ~/.kdk/environments.yaml
+func (c *KdkEnvConfig) envExists(name string) bool {
+	if validateName(name) != nil {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(c.ConfigRootDir(), name, "config.yaml")); err == nil {
+		return true
+	}
+	environments, err := c.readEnvironments()
+	_, ok := environments[name]
+	return err == nil && ok
+}
+
+// Set the current KDK environment, targeted by commands run without --name
+func (c *KdkEnvConfig) SetCurrent(name string) error {
+	if !c.envExists(name) {
+		return wrapError(ErrConfigNotFound, fmt.Errorf("KDK environment [%s] does not exist", name))
+	}
+	return ioutil.WriteFile(c.currentPath(), []byte(name+"\n"), 0600)
+}
+
+// Get the current KDK environment.  Without a valid ~/.kdk/current, the only
+// environment is current when exactly one exists, otherwise "" is returned.
+func (c *KdkEnvConfig) GetCurrent() (string, error) {
+	data, err := ioutil.ReadFile(c.currentPath())
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if name := strings.TrimSpace(string(data)); name != "" {
+		if c.envExists(name) {
+			return name, nil
+		}
+		log.Warnf("Current KDK environment [%s] no longer exists", name)
+	}
+
+	names, err := c.ListKdkConfigs()
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	return "", nil
+}
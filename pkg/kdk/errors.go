@@ -0,0 +1,113 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// Common failures returned by kdk operations.  Errors returned from this
+// package wrap these so that callers may branch with errors.Is
+var (
+	ErrConfigNotFound    = errors.New("KDK config not found")
+	ErrDockerUnavailable = errors.New("docker daemon unavailable")
+	ErrDockerTimeout     = errors.New("docker API request timed out")
+	ErrPortInUse         = errors.New("KDK port already in use")
+	ErrImageNotFound     = errors.New("KDK image not found")
+	ErrContainerNotFound = errors.New("KDK container not found")
+	ErrConfigLocked      = errors.New("this environment is locked")
+	ErrKeyPairMismatch   = errors.New("KDK ssh keypair mismatch")
+)
+
+// Process exit codes of kdk commands, so that scripts may branch on specific failures.  These are
+// a stable contract: add new codes, but never renumber existing ones.
+const (
+	ExitOK                = 0
+	ExitError             = 1 // any failure without a more specific code
+	ExitUsage             = 2 // invalid command line
+	ExitConfigNotFound    = 3
+	ExitDockerUnavailable = 4
+	ExitDockerTimeout     = 5
+	ExitPortInUse         = 6
+	ExitImageNotFound     = 7
+	ExitContainerNotFound = 8
+	ExitConfigLocked      = 9
+	ExitKeyPairMismatch   = 10
+)
+
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{ErrConfigNotFound, ExitConfigNotFound},
+	{ErrDockerUnavailable, ExitDockerUnavailable},
+	{ErrDockerTimeout, ExitDockerTimeout},
+	{ErrPortInUse, ExitPortInUse},
+	{ErrImageNotFound, ExitImageNotFound},
+	{ErrContainerNotFound, ExitContainerNotFound},
+	{ErrConfigLocked, ExitConfigLocked},
+	{ErrKeyPairMismatch, ExitKeyPairMismatch},
+}
+
+// Process exit code for err: 0 if nil, the code of its typed kdk error if any, otherwise ExitError.
+// Docker client errors logged without wrapping are translated too.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	err = wrapDockerError(err)
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return ExitError
+}
+
+// Wrap err with a typed kdk error, preserving the original message
+func wrapError(kdkErr error, err error) error {
+	return fmt.Errorf("%w: %v", kdkErr, err)
+}
+
+// Translate docker client errors into typed kdk errors where possible
+func wrapDockerError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if isDockerTimeout(err) {
+		return wrapError(ErrDockerTimeout, err)
+	}
+	if client.IsErrConnectionFailed(err) {
+		return wrapError(ErrDockerUnavailable, err)
+	}
+	// Docker reports host port collisions as a string error at container start
+	if strings.Contains(err.Error(), "port is already allocated") ||
+		strings.Contains(err.Error(), "address already in use") {
+		return wrapError(ErrPortInUse, err)
+	}
+	return err
+}
+
+// Translate docker client errors for image operations, where "not found" refers to the image
+func wrapImageError(err error) error {
+	if err != nil && client.IsErrNotFound(err) {
+		return wrapError(ErrImageNotFound, err)
+	}
+	return wrapDockerError(err)
+}
@@ -0,0 +1,55 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Run a command within the KDK container as user ("" for the container default of root),
+// returning its combined output and exit code.  The exec never has a tty, whatever the container
+// Tty setting, so its stdout and stderr are multiplexed.
+func (c *KdkEnvConfig) containerExec(user string, cmd ...string) (string, int, error) {
+	exec, err := c.DockerClient.ContainerExecCreate(c.Ctx, c.ContainerName(), types.ExecConfig{
+		User:         user,
+		Tty:          false,
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", 0, wrapDockerError(err)
+	}
+
+	resp, err := c.DockerClient.ContainerExecAttach(c.Ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return "", 0, wrapDockerError(err)
+	}
+	defer resp.Close()
+
+	var out bytes.Buffer
+	if _, err := stdcopy.StdCopy(&out, &out, resp.Reader); err != nil {
+		return out.String(), 0, err
+	}
+
+	inspect, err := c.DockerClient.ContainerExecInspect(c.Ctx, exec.ID)
+	if err != nil {
+		return out.String(), 0, wrapDockerError(err)
+	}
+	return out.String(), inspect.ExitCode, nil
+}
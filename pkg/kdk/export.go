@@ -0,0 +1,356 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exported environments are tarballs laid out relative to ~/.kdk:
+//   <KDK_NAME>/config.yaml    the environment config dir
+//   ssh/id_rsa.pub            the KDK public key
+//   ssh/id_rsa                the KDK private key (optional)
+//   volumes/<VOLUME>.tar      named volume backups, by BackupVolume (optional)
+// optionally gzip compressed.
+
+// Contents and format of an environment export
+type ExportOptions struct {
+	IncludePrivateKey bool
+	IncludeVolumes    bool // backups of the named volumes the environment declares
+	Compress          bool // gzip the tarball
+}
+
+// Export the config of environment `name`, its config dir, and the KDK keypair to the tarball `dst`, returning
+// its size.  The tarball may hold secrets, so it is only readable by the user.
+func (c *KdkEnvConfig) ExportEnv(name, dst string, opts ExportOptions) (int64, error) {
+	if err := c.exportEnv(name, dst, opts); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (c *KdkEnvConfig) exportEnv(name, dst string, opts ExportOptions) error {
+	// The config may be kept in ~/.kdk/environments.yaml rather than the config dir
+	env := &KdkEnvConfig{HomeDir: c.HomeDir}
+	env.ConfigFile.AppConfig.Name = name
+	config, err := env.readConfig()
+	if os.IsNotExist(err) {
+		return wrapError(ErrConfigNotFound, err)
+	} else if err != nil {
+		return err
+	}
+	envDir := env.ConfigDir()
+	configEntry := path.Join(name, "config.yaml")
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// an existing dst keeps its mode on open
+	if err := out.Chmod(0600); err != nil {
+		return err
+	}
+	var w io.Writer = out
+	var gz *gzip.Writer
+	if opts.Compress {
+		gz = gzip.NewWriter(out)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+
+	// Add the environment config dir, if any, and the config as read
+	if _, err := os.Stat(envDir); err == nil {
+		err = filepath.Walk(envDir, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(c.ConfigRootDir(), file)
+			if err != nil {
+				return err
+			}
+			if filepath.ToSlash(rel) == configEntry {
+				return nil
+			}
+			return addToTar(tw, file, filepath.ToSlash(rel), info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := addDataToTar(tw, configEntry, config, 0600); err != nil {
+		return err
+	}
+
+	// Add the keypair
+	keys := []string{c.PublicKeyPath()}
+	if opts.IncludePrivateKey {
+		keys = append(keys, c.PrivateKeyPath())
+	}
+	for _, key := range keys {
+		info, err := os.Stat(key)
+		if os.IsNotExist(err) {
+			log.Warnf("KDK key [%s] not found.  Skipping export of key", key)
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := addToTar(tw, key, path.Join("ssh", filepath.Base(key)), info); err != nil {
+			return err
+		}
+	}
+
+	if opts.IncludeVolumes {
+		if err := c.exportVolumes(tw, name); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// A tar reader of the environment archive src, gzip compressed or not.  Close the returned closer when done.
+func openArchive(src string) (*tar.Reader, io.Closer, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	br := bufio.NewReader(in)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		in.Close()
+		return nil, nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			in.Close()
+			return nil, nil, err
+		}
+		return tar.NewReader(gz), in, nil
+	}
+	return tar.NewReader(br), in, nil
+}
+
+// Add backups of the named volumes of environment `name` to the tarball
+func (c *KdkEnvConfig) exportVolumes(tw *tar.Writer, name string) error {
+	env, err := c.loadEnv(name)
+	if err != nil {
+		return err
+	}
+	for _, volume := range env.namedVolumes() {
+		if err := c.exportVolume(tw, volume); err != nil {
+			return fmt.Errorf("failed to export volume [%s]: %v", volume, err)
+		}
+		log.Infof("Exported volume [%s]", volume)
+	}
+	return nil
+}
+
+func (c *KdkEnvConfig) exportVolume(tw *tar.Writer, volume string) error {
+	tmp, err := ioutil.TempFile("", "kdk-volume-")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := c.BackupVolume(volume, tmp.Name()); err != nil {
+		return err
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return err
+	}
+	return addToTar(tw, tmp.Name(), path.Join("volumes", volume+".tar"), info)
+}
+
+// Restore a volume backup from the tarball
+func (c *KdkEnvConfig) importVolume(tr *tar.Reader, volume string) error {
+	tmp, err := ioutil.TempFile("", "kdk-volume-")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := extractFromTar(tr, tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return c.RestoreVolume(volume, tmp.Name())
+}
+
+// Import an environment tarball created by ExportEnv into ~/.kdk, returning the environment name.
+// Existing KDK keys are never overwritten.
+func (c *KdkEnvConfig) ImportEnv(src string) (name string, err error) {
+	name, err = envNameFromTar(src)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(c.ConfigRootDir(), name)); err == nil {
+		return "", fmt.Errorf("KDK environment [%s] already exists", name)
+	}
+
+	tr, closer, err := openArchive(src)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		entry := path.Clean(hdr.Name)
+		if dir, file := path.Split(entry); dir == "volumes/" && hdr.Typeflag == tar.TypeReg {
+			volume := strings.TrimSuffix(file, ".tar")
+			if err := c.importVolume(tr, volume); err != nil {
+				return "", fmt.Errorf("failed to import volume [%s]: %v", volume, err)
+			}
+			log.Infof("Imported volume [%s]", volume)
+			continue
+		}
+		target := filepath.Join(c.ConfigRootDir(), filepath.FromSlash(entry))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return "", err
+			}
+		case tar.TypeReg:
+			if _, err := os.Stat(target); err == nil {
+				log.Warnf("File [%s] already exists.  Skipping import of file", target)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return "", err
+			}
+			if err := extractFromTar(tr, target, os.FileMode(hdr.Mode).Perm()); err != nil {
+				return "", err
+			}
+		}
+	}
+	return name, nil
+}
+
+// Scan the tarball for the environment it contains, rejecting entries that would escape ~/.kdk
+func envNameFromTar(src string) (string, error) {
+	tr, closer, err := openArchive(src)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	name := ""
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		entry := path.Clean(hdr.Name)
+		if path.IsAbs(entry) || entry == ".." || strings.HasPrefix(entry, "../") {
+			return "", fmt.Errorf("invalid path [%s] in KDK environment archive", hdr.Name)
+		}
+		top := strings.Split(entry, "/")[0]
+		if top == "ssh" || top == "volumes" {
+			continue
+		}
+		if name != "" && name != top {
+			return "", errors.New("KDK environment archive contains more than one environment")
+		}
+		name = top
+	}
+	if name == "" {
+		return "", errors.New("KDK environment archive does not contain an environment")
+	}
+	return name, nil
+}
+
+func addToTar(tw *tar.Writer, file string, name string, info os.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	_, err = io.Copy(tw, in)
+	return err
+}
+
+// Add data as a regular file to the tarball
+func addDataToTar(tw *tar.Writer, name string, data []byte, mode os.FileMode) error {
+	hdr := &tar.Header{Name: name, Mode: int64(mode), Size: int64(len(data)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func extractFromTar(tr *tar.Reader, target string, mode os.FileMode) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, tr); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,78 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/codeskyblue/go-sh"
+	log "github.com/sirupsen/logrus"
+)
+
+// Host commands run around the KDK container lifecycle, e.g. to connect a VPN before start
+type HooksConfig struct {
+	PreStart  []string `json:",omitempty"`
+	PostStart []string `json:",omitempty"`
+	PreStop   []string `json:",omitempty"`
+
+	// Continue when a pre-hook fails.  Post-hook failures are always only logged
+	IgnoreErrors bool
+}
+
+// Run the hooks of a lifecycle phase on the host, with the environment in KDK_* env vars.
+// A failing pre-hook aborts the operation unless Hooks.IgnoreErrors is set.
+func (c *KdkEnvConfig) runHooks(phase string) error {
+	hooks := c.ConfigFile.AppConfig.Hooks
+	if hooks == nil {
+		return nil
+	}
+	commands := map[string][]string{
+		"pre-start":  hooks.PreStart,
+		"post-start": hooks.PostStart,
+		"pre-stop":   hooks.PreStop,
+	}[phase]
+
+	for _, command := range commands {
+		session := sh.NewSession()
+		session.SetEnv("KDK_HOOK", phase)
+		session.SetEnv("KDK_NAME", c.ConfigFile.AppConfig.Name)
+		session.SetEnv("KDK_CONTAINER_NAME", c.ContainerName())
+		session.SetEnv("KDK_USERNAME", c.User())
+		session.SetEnv("KDK_PORT", c.ConfigFile.AppConfig.Port)
+		session.SetEnv("KDK_CONFIG_PATH", c.ConfigPath())
+		if runtime.GOOS == "windows" {
+			session.Command("cmd", "/c", command)
+		} else {
+			session.Command("sh", "-c", command)
+		}
+
+		log.Infof("Running %s hook [%s]", phase, command)
+		out, err := session.CombinedOutput()
+		logger := log.WithField("hook", phase)
+		if output := strings.TrimSpace(string(out)); output != "" {
+			logger.Info(output)
+		}
+		if err == nil {
+			continue
+		}
+		if strings.HasPrefix(phase, "pre-") && !hooks.IgnoreErrors {
+			return fmt.Errorf("%s hook [%s] failed: %v", phase, command, err)
+		}
+		logger.WithField("error", err).Warnf("%s hook [%s] failed", phase, command)
+	}
+	return nil
+}
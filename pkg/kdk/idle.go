@@ -0,0 +1,52 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Shortest IdleTimeout, so a brief network drop does not stop the KDK container
+const minIdleTimeout = time.Minute
+
+// An idle timeout, when provided, is a duration of at least a minute.  It is enforced by
+// kdk-idle-monitor, which the KDK bootstrap starts.
+func validateIdleTimeout(timeout string, bootstrap bool) error {
+	if timeout == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid IdleTimeout [%s]: %v", timeout, err)
+	}
+	if d < minIdleTimeout {
+		return fmt.Errorf("invalid IdleTimeout [%s]: must be at least %v", timeout, minIdleTimeout)
+	}
+	if !bootstrap {
+		return fmt.Errorf("invalid IdleTimeout [%s]: requires the KDK bootstrap (Bootstrap true)", timeout)
+	}
+	return nil
+}
+
+// Environment for kdk-idle-monitor: the idle timeout in seconds
+func (c *KdkEnvConfig) idleTimeoutEnv() []string {
+	d, err := time.ParseDuration(c.ConfigFile.AppConfig.IdleTimeout)
+	if err != nil || d <= 0 {
+		return nil
+	}
+	return []string{"KDK_IDLE_TIMEOUT=" + strconv.Itoa(int(d.Seconds()))}
+}
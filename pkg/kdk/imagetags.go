@@ -0,0 +1,79 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Key of the ImageTags entry used when no entry matches the image platform
+const defaultImageTagKey = "default"
+
+// The platform the KDK image runs on: the configured Platform, otherwise linux on the host arch
+func imagePlatform(platform string) string {
+	if platform != "" {
+		return platform
+	}
+	return "linux/" + runtime.GOARCH
+}
+
+// Resolve the KDK image tag.  An explicit tag always wins.  Otherwise the tag is the ImageTags
+// entry of the most specific match of the platform: os/arch/variant, os/arch, arch, then "default".
+func resolveImageTag(tag string, tags map[string]string, platform string) (string, error) {
+	if tag != "" || len(tags) == 0 {
+		return tag, nil
+	}
+	parts := strings.Split(platform, "/")
+	keys := []string{platform}
+	if len(parts) > 2 {
+		keys = append(keys, strings.Join(parts[:2], "/"))
+	}
+	if len(parts) > 1 {
+		keys = append(keys, parts[1])
+	}
+	keys = append(keys, defaultImageTagKey)
+	for _, key := range keys {
+		if resolved, ok := tags[key]; ok {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("invalid AppConfig.ImageTags: no entry for platform [%s], and no %q entry", platform, defaultImageTagKey)
+}
+
+// The KDK image tag: ImageTag when set, otherwise resolved from ImageTags for the image platform.
+// Empty when ImageTags has no entry for the platform.
+func (c *KdkEnvConfig) ImageTag() string {
+	tag, _ := resolveImageTag(c.ConfigFile.AppConfig.ImageTag, c.ConfigFile.AppConfig.ImageTags, imagePlatform(c.ConfigFile.AppConfig.Platform))
+	return tag
+}
+
+// The image repository and the resolved image tag must be valid
+func (c *KdkEnvConfig) validateImageConfig() error {
+	for key, tag := range c.ConfigFile.AppConfig.ImageTags {
+		if key == "" {
+			return fmt.Errorf("invalid AppConfig.ImageTags: empty platform for tag [%s]", tag)
+		}
+		if !imageTag.MatchString(tag) {
+			return fmt.Errorf("invalid AppConfig.ImageTags [%s]: tag [%s] must be letters, digits, '_', '.', or '-' without spaces", key, tag)
+		}
+	}
+	tag, err := resolveImageTag(c.ConfigFile.AppConfig.ImageTag, c.ConfigFile.AppConfig.ImageTags, imagePlatform(c.ConfigFile.AppConfig.Platform))
+	if err != nil {
+		return err
+	}
+	return validateImage(c.ConfigFile.AppConfig.ImageRepository, tag)
+}
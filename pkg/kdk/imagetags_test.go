@@ -0,0 +1,88 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveImageTag(t *testing.T) {
+
+	tags := map[string]string{
+		"amd64":            "debian-latest",
+		"arm64":            "debian-arm64",
+		"linux/arm/v7":     "alpine-armv7",
+		"windows/amd64":    "windows-latest",
+		defaultImageTagKey: "debian-latest",
+	}
+	for _, test := range []struct {
+		platform string
+		want     string
+	}{
+		{"linux/amd64", "debian-latest"},
+		{"linux/arm64", "debian-arm64"},
+		{"linux/arm64/v8", "debian-arm64"},
+		{"linux/arm/v7", "alpine-armv7"},
+		{"windows/amd64", "windows-latest"},
+		{"linux/s390x", "debian-latest"},
+	} {
+		tag, err := resolveImageTag("", tags, test.platform)
+		if err != nil {
+			t.Logf("resolveImageTag failed for platform [%s]. %v", test.platform, err)
+			t.FailNow()
+		}
+		if tag != test.want {
+			t.Logf("resolveImageTag resolved platform [%s] to [%s], expected [%s]", test.platform, tag, test.want)
+			t.FailNow()
+		}
+	}
+}
+
+func TestResolveImageTagExplicit(t *testing.T) {
+
+	tag, err := resolveImageTag("1.0.0", map[string]string{"amd64": "debian-latest"}, "linux/amd64")
+	if err != nil || tag != "1.0.0" {
+		t.Logf("resolveImageTag did not prefer the explicit tag, resolved [%s]. %v", tag, err)
+		t.FailNow()
+	}
+
+	tag, err = resolveImageTag("1.0.0", nil, "linux/amd64")
+	if err != nil || tag != "1.0.0" {
+		t.Logf("resolveImageTag changed the tag without ImageTags, resolved [%s]. %v", tag, err)
+		t.FailNow()
+	}
+}
+
+func TestResolveImageTagMissing(t *testing.T) {
+
+	_, err := resolveImageTag("", map[string]string{"amd64": "debian-latest"}, "linux/arm64")
+	if err == nil || !strings.Contains(err.Error(), "linux/arm64") {
+		t.Log("resolveImageTag did not report the platform without an ImageTags entry.", err)
+		t.FailNow()
+	}
+}
+
+func TestImagePlatform(t *testing.T) {
+
+	if platform := imagePlatform("linux/arm64"); platform != "linux/arm64" {
+		t.Logf("imagePlatform ignored the configured platform, returned [%s]", platform)
+		t.FailNow()
+	}
+	if platform := imagePlatform(""); !strings.HasPrefix(platform, "linux/") {
+		t.Logf("imagePlatform did not default to a linux platform, returned [%s]", platform)
+		t.FailNow()
+	}
+}
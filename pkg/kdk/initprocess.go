@@ -0,0 +1,59 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"path"
+
+	"github.com/docker/docker/api/types/strslice"
+	log "github.com/sirupsen/logrus"
+)
+
+// Entrypoints which are themselves an init, and must run as pid 1
+var initEntrypoints = map[string]bool{
+	"systemd":   true,
+	"init":      true,
+	"tini":      true,
+	"s6-svscan": true,
+}
+
+// The command the container runs: the config Entrypoint and Cmd, or those of the image
+func (c *KdkEnvConfig) containerCommand(entrypoint, cmd strslice.StrSlice, image string) []string {
+	if len(entrypoint) > 0 {
+		return entrypoint
+	}
+	if len(cmd) > 0 {
+		return cmd
+	}
+	inspect, _, err := c.DockerClient.ImageInspectWithRaw(c.Ctx, image)
+	if err != nil || inspect.Config == nil {
+		return nil
+	}
+	if len(inspect.Config.Entrypoint) > 0 {
+		return inspect.Config.Entrypoint
+	}
+	return inspect.Config.Cmd
+}
+
+// Whether docker's init may run as pid 1.  An entrypoint such as systemd, which the stock KDK
+// image runs, refuses to boot unless it is pid 1 itself.
+func (c *KdkEnvConfig) allowInit(entrypoint, cmd strslice.StrSlice, image string) bool {
+	command := c.containerCommand(entrypoint, cmd, image)
+	if len(command) > 0 && initEntrypoints[path.Base(command[0])] {
+		log.Warnf("KDK image [%s] runs an init [%s] which must be pid 1.  Ignoring AppConfig.Init", image, command[0])
+		return false
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Where the bootstrap reads the KDK public key from, to copy into authorized_keys
+const bootstrapKeyPath = "/tmp/id_rsa.pub"
+
+// The KDK ssh public key: PublicKeyData when provided, otherwise the key under ~/.kdk/ssh
+func (c *KdkEnvConfig) readPublicKey() ([]byte, error) {
+	if c.PublicKeyData != nil {
+		return c.PublicKeyData, nil
+	}
+	if c.InMemory {
+		return nil, errors.New("an in-memory KDK config requires PublicKeyData")
+	}
+	return ioutil.ReadFile(c.PublicKeyPath())
+}
+
+// An in-memory KDK has no public key file on the host to mount for the bootstrap.  Drop the mount,
+// which a config.yaml from `kdk init` has, and copyBootstrapKey copies the key in at start instead.
+func (c *KdkEnvConfig) dropBootstrapKeyMount(config *container.Config, hostConfig *container.HostConfig) {
+	if !c.InMemory {
+		return
+	}
+	var mounts []mount.Mount
+	for _, m := range hostConfig.Mounts {
+		if m.Target != bootstrapKeyPath {
+			mounts = append(mounts, m)
+		}
+	}
+	hostConfig.Mounts = mounts
+	delete(config.Volumes, bootstrapKeyPath)
+}
+
+// Copy PublicKeyData to where the bootstrap reads it, for an in-memory KDK
+func (c *KdkEnvConfig) copyBootstrapKey() error {
+	if !c.InMemory {
+		return nil
+	}
+	publicKey, err := c.readPublicKey()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: path.Base(bootstrapKeyPath), Mode: 0644, Size: int64(len(publicKey))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(publicKey); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := c.DockerClient.CopyToContainer(c.Ctx, c.ContainerName(), path.Dir(bootstrapKeyPath), &buf, types.CopyToContainerOptions{}); err != nil {
+		return wrapDockerError(err)
+	}
+	return nil
+}
+
+// Run a command within the KDK container as user ("" for root) through the docker API, returning
+// its combined output and exit code.  Unlike Exec, it needs no ssh key, so it works for in-memory KDKs.
+func (c *KdkEnvConfig) ContainerExec(user string, cmd ...string) (string, int, error) {
+	return c.containerExec(user, cmd...)
+}
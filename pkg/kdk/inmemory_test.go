@@ -0,0 +1,140 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestReadPublicKey(t *testing.T) {
+
+	env, cleanup := tempHomeEnv(t, "kdk")
+	defer cleanup()
+
+	// the key under ~/.kdk/ssh
+	if err := os.MkdirAll(env.KeypairDir(), 0700); err != nil {
+		t.Log("Failed to create keypair dir.", err)
+		t.FailNow()
+	}
+	fileKey := []byte("ssh-rsa AAAAfile kdk\n")
+	if err := ioutil.WriteFile(env.PublicKeyPath(), fileKey, 0644); err != nil {
+		t.Log("Failed to write public key.", err)
+		t.FailNow()
+	}
+	if key, err := env.readPublicKey(); err != nil || !bytes.Equal(key, fileKey) {
+		t.Logf("readPublicKey returned [%s], expected the key file. %v", key, err)
+		t.FailNow()
+	}
+
+	// PublicKeyData takes precedence
+	dataKey := []byte("ssh-rsa AAAAdata kdk\n")
+	env.PublicKeyData = dataKey
+	if key, err := env.readPublicKey(); err != nil || !bytes.Equal(key, dataKey) {
+		t.Logf("readPublicKey returned [%s], expected PublicKeyData. %v", key, err)
+		t.FailNow()
+	}
+
+	// an in-memory KDK never reads the key file
+	env.PublicKeyData = nil
+	env.InMemory = true
+	if _, err := env.readPublicKey(); err == nil {
+		t.Log("readPublicKey read the key file of an in-memory KDK without PublicKeyData")
+		t.FailNow()
+	}
+}
+
+func TestDropBootstrapKeyMount(t *testing.T) {
+
+	newConfigs := func() (*container.Config, *container.HostConfig) {
+		config := &container.Config{Volumes: map[string]struct{}{bootstrapKeyPath: {}, "/home/kdk/work": {}}}
+		hostConfig := &container.HostConfig{Mounts: []mount.Mount{
+			{Type: mount.TypeBind, Source: "/home/kdk/.kdk/ssh/id_rsa.pub", Target: bootstrapKeyPath, ReadOnly: true},
+			{Type: mount.TypeBind, Source: "/home/kdk/work", Target: "/home/kdk/work"},
+		}}
+		return config, hostConfig
+	}
+
+	// the key mount is kept for a KDK with a key file
+	env := &KdkEnvConfig{}
+	config, hostConfig := newConfigs()
+	env.dropBootstrapKeyMount(config, hostConfig)
+	if len(hostConfig.Mounts) != 2 || len(config.Volumes) != 2 {
+		t.Logf("dropBootstrapKeyMount changed the mounts of a KDK with a key file: %v", hostConfig.Mounts)
+		t.FailNow()
+	}
+
+	env.InMemory = true
+	config, hostConfig = newConfigs()
+	env.dropBootstrapKeyMount(config, hostConfig)
+	if len(hostConfig.Mounts) != 1 || hostConfig.Mounts[0].Target != "/home/kdk/work" {
+		t.Logf("dropBootstrapKeyMount kept %v, expected only the /home/kdk/work mount", hostConfig.Mounts)
+		t.FailNow()
+	}
+	if _, ok := config.Volumes[bootstrapKeyPath]; ok || len(config.Volumes) != 1 {
+		t.Logf("dropBootstrapKeyMount kept volumes %v, expected only /home/kdk/work", config.Volumes)
+		t.FailNow()
+	}
+}
+
+// An in-memory KDK writes nothing under its home directory
+func TestInMemoryShortCircuits(t *testing.T) {
+
+	env, cleanup := tempHomeEnv(t, "kdk")
+	defer cleanup()
+	env.InMemory = true
+	env.PublicKeyData = []byte("ssh-rsa AAAAdata kdk\n")
+	env.ConfigFile.AppConfig.Port = "2022"
+	env.ConfigFile.AppConfig.KeyProvider = "agent"
+	env.ConfigFile.AppConfig.Kubeconfig = filepath.Join(env.Home(), ".kube", "config")
+	env.ConfigFile.AppConfig.KubeContexts = []string{"dev"}
+	env.ConfigFile.HostConfig = &container.HostConfig{PortBindings: nat.PortMap{
+		"2022/tcp": []nat.PortBinding{{HostPort: "2022"}},
+	}}
+
+	if err := env.FetchKey(); err != nil {
+		t.Log("FetchKey of an in-memory KDK failed.", err)
+		t.FailNow()
+	}
+	env.refreshKubeconfig()
+	env.writeStatusFile("starting")
+	env.removeStatusFile()
+	env.refreshSshConfigEntry()
+	if err := env.setPort("2023"); err != nil {
+		t.Log("setPort of an in-memory KDK failed.", err)
+		t.FailNow()
+	}
+	if env.ConfigFile.HostConfig.PortBindings["2022/tcp"][0].HostPort != "2023" {
+		t.Logf("setPort did not update the port binding: %v", env.ConfigFile.HostConfig.PortBindings)
+		t.FailNow()
+	}
+
+	files, err := ioutil.ReadDir(env.Home())
+	if err != nil {
+		t.Log("Failed to read temp home dir.", err)
+		t.FailNow()
+	}
+	for _, f := range files {
+		t.Logf("In-memory KDK wrote %s", filepath.Join(env.Home(), f.Name()))
+		t.Fail()
+	}
+}
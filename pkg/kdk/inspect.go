@@ -0,0 +1,53 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Returns the raw docker inspect of the KDK container, found by its kdk label and name
+func (c *KdkEnvConfig) Inspect() (types.ContainerJSON, error) {
+	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "kdk"), filters.Arg("name", c.ContainerName())),
+	})
+	if err != nil {
+		return types.ContainerJSON{}, wrapDockerError(err)
+	}
+	// the docker name filter is a substring match
+	for _, container := range containers {
+		for _, name := range container.Names {
+			if name == "/"+c.ContainerName() {
+				inspect, err := c.DockerClient.ContainerInspect(c.Ctx, container.ID)
+				return inspect, wrapDockerError(err)
+			}
+		}
+	}
+	return types.ContainerJSON{}, wrapError(ErrContainerNotFound, fmt.Errorf("no KDK container named [%s]", c.ContainerName()))
+}
+
+// Returns the raw docker inspect of the KDK container as indented JSON
+func (c *KdkEnvConfig) InspectJSON() ([]byte, error) {
+	inspect, err := c.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(inspect, "", "  ")
+}
@@ -0,0 +1,283 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/ghodss/yaml"
+)
+
+// Minimal Kubernetes object types for ExportK8s, to avoid depending on the Kubernetes API packages
+
+type k8sMetadata struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type k8sPod struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Metadata   k8sMetadata `json:"metadata"`
+	Spec       k8sPodSpec  `json:"spec"`
+}
+
+type k8sPodSpec struct {
+	Hostname   string         `json:"hostname,omitempty"`
+	Containers []k8sContainer `json:"containers"`
+	Volumes    []k8sVolume    `json:"volumes,omitempty"`
+}
+
+type k8sContainer struct {
+	Name            string              `json:"name"`
+	Image           string              `json:"image"`
+	Command         []string            `json:"command,omitempty"`
+	Args            []string            `json:"args,omitempty"`
+	Ports           []k8sContainerPort  `json:"ports,omitempty"`
+	Env             []k8sEnvVar         `json:"env,omitempty"`
+	VolumeMounts    []k8sVolumeMount    `json:"volumeMounts,omitempty"`
+	SecurityContext *k8sSecurityContext `json:"securityContext,omitempty"`
+	TTY             bool                `json:"tty,omitempty"`
+}
+
+type k8sContainerPort struct {
+	Name          string `json:"name,omitempty"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+}
+
+type k8sEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	SubPath   string `json:"subPath,omitempty"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+type k8sSecurityContext struct {
+	Privileged bool `json:"privileged"`
+}
+
+type k8sVolume struct {
+	Name                  string                    `json:"name"`
+	HostPath              *k8sHostPath              `json:"hostPath,omitempty"`
+	PersistentVolumeClaim *k8sPersistentVolumeClaim `json:"persistentVolumeClaim,omitempty"`
+	Secret                *k8sSecretVolume          `json:"secret,omitempty"`
+}
+
+type k8sHostPath struct {
+	Path string `json:"path"`
+}
+
+type k8sPersistentVolumeClaim struct {
+	ClaimName string `json:"claimName"`
+}
+
+type k8sSecretVolume struct {
+	SecretName string `json:"secretName"`
+}
+
+type k8sSecret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   k8sMetadata       `json:"metadata"`
+	Type       string            `json:"type"`
+	StringData map[string]string `json:"stringData"`
+}
+
+type k8sService struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   k8sMetadata    `json:"metadata"`
+	Spec       k8sServiceSpec `json:"spec"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `json:"selector"`
+	Ports    []k8sServicePort  `json:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `json:"name"`
+	Port       int    `json:"port"`
+	TargetPort int    `json:"targetPort"`
+	Protocol   string `json:"protocol"`
+}
+
+// Characters not allowed in a Kubernetes (RFC 1123) name
+var k8sInvalidName = regexp.MustCompile(`[^a-z0-9-]+`)
+
+func k8sName(name string) string {
+	name = strings.Trim(k8sInvalidName.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// Translate the effective KDK config into a Kubernetes Pod running the KDK container, a Service for
+// its ssh port, and a Secret holding the KDK public key for the bootstrap, as a multi-document YAML
+// manifest.  Bind mounts become hostPath volumes, which refer to the node filesystem, and named
+// volumes become PersistentVolumeClaims of the same name, which must exist.  annotations are set on
+// all objects.
+func (c *KdkEnvConfig) ExportK8s(annotations map[string]string) ([]byte, error) {
+	effective, err := c.EffectiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	name := k8sName(c.ConfigFile.AppConfig.Name)
+	labels := map[string]string{"app": "kdk", "kdk.name": name}
+
+	kdkContainer := k8sContainer{
+		Name:    "kdk",
+		Image:   effective.ContainerConfig.Image,
+		Command: effective.ContainerConfig.Entrypoint,
+		Args:    effective.ContainerConfig.Cmd,
+		TTY:     effective.ContainerConfig.Tty,
+	}
+	if effective.HostConfig.Privileged {
+		kdkContainer.SecurityContext = &k8sSecurityContext{Privileged: true}
+	}
+
+	var ports []string
+	for port := range effective.ContainerConfig.ExposedPorts {
+		ports = append(ports, string(port))
+	}
+	sort.Strings(ports)
+	for _, port := range ports {
+		number, proto := splitPort(port)
+		containerPort := k8sContainerPort{ContainerPort: number, Protocol: strings.ToUpper(proto)}
+		if number == 2022 {
+			containerPort.Name = "ssh"
+		}
+		kdkContainer.Ports = append(kdkContainer.Ports, containerPort)
+	}
+
+	for _, env := range effective.ContainerConfig.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			kdkContainer.Env = append(kdkContainer.Env, k8sEnvVar{Name: parts[0], Value: parts[1]})
+		}
+	}
+
+	// Mounts, then legacy binds (source:target[:options])
+	mounts := effective.HostConfig.Mounts
+	for _, bind := range effective.HostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		m := mount.Mount{Type: mount.TypeBind, Source: parts[0], Target: parts[1]}
+		if len(parts) > 2 {
+			m.ReadOnly = strings.Contains(parts[2], "ro")
+		}
+		mounts = append(mounts, m)
+	}
+	var volumes []k8sVolume
+	for i, m := range mounts {
+		// The public key is on the host, not the node.  It is mounted from the Secret below instead.
+		if m.Target == bootstrapKeyPath {
+			continue
+		}
+		volume := k8sVolume{Name: "mount-" + strconv.Itoa(i)}
+		switch {
+		case m.Type == mount.TypeBind:
+			volume.HostPath = &k8sHostPath{Path: m.Source}
+		case m.Type == mount.TypeVolume && m.Source != "":
+			volume.Name = k8sName(m.Source)
+			volume.PersistentVolumeClaim = &k8sPersistentVolumeClaim{ClaimName: volume.Name}
+		default:
+			continue
+		}
+		volumes = append(volumes, volume)
+		kdkContainer.VolumeMounts = append(kdkContainer.VolumeMounts,
+			k8sVolumeMount{Name: volume.Name, MountPath: m.Target, ReadOnly: m.ReadOnly})
+	}
+
+	// The bootstrap copies the public key into authorized_keys
+	var secret *k8sSecret
+	if c.bootstrap() {
+		publicKey, err := c.readPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		secretName := k8sName(name + "-ssh")
+		keyFile := path.Base(bootstrapKeyPath)
+		secret = &k8sSecret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Metadata:   k8sMetadata{Name: secretName, Labels: labels, Annotations: annotations},
+			Type:       "Opaque",
+			StringData: map[string]string{keyFile: string(publicKey)},
+		}
+		volumes = append(volumes, k8sVolume{Name: "ssh-public-key", Secret: &k8sSecretVolume{SecretName: secretName}})
+		kdkContainer.VolumeMounts = append(kdkContainer.VolumeMounts,
+			k8sVolumeMount{Name: "ssh-public-key", MountPath: bootstrapKeyPath, SubPath: keyFile, ReadOnly: true})
+	}
+
+	pod := k8sPod{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Metadata:   k8sMetadata{Name: name, Labels: labels, Annotations: annotations},
+		Spec: k8sPodSpec{
+			Hostname:   k8sName(effective.ContainerConfig.Hostname),
+			Containers: []k8sContainer{kdkContainer},
+			Volumes:    volumes,
+		},
+	}
+	service := k8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sMetadata{Name: name, Labels: labels, Annotations: annotations},
+		Spec: k8sServiceSpec{
+			Selector: labels,
+			Ports:    []k8sServicePort{{Name: "ssh", Port: 2022, TargetPort: 2022, Protocol: "TCP"}},
+		},
+	}
+
+	objects := []interface{}{pod, service}
+	if secret != nil {
+		objects = append([]interface{}{secret}, objects...)
+	}
+	var manifest []byte
+	for _, object := range objects {
+		objectYAML, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		manifest = append(append(manifest, "---\n"...), objectYAML...)
+	}
+	return manifest, nil
+}
+
+// Split a docker port ("2022/tcp") into its number and protocol
+func splitPort(port string) (int, string) {
+	parts := strings.SplitN(port, "/", 2)
+	number, _ := strconv.Atoi(parts[0])
+	if len(parts) == 1 {
+		return number, "tcp"
+	}
+	return number, parts[1]
+}
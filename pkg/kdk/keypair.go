@@ -0,0 +1,82 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cisco-sso/kdk/pkg/ssh"
+	log "github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Check that the public key matches the private key, since the container trusts only the public key.
+// A missing public key is regenerated from the private key.  A mismatched pair is an error.
+func checkKeyPair(privateKeyPath, publicKeyPath string) error {
+	privateKey, err := ioutil.ReadFile(privateKeyPath)
+	if err != nil {
+		return err
+	}
+	signer, err := gossh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return fmt.Errorf("invalid KDK ssh private key %s: %v", privateKeyPath, err)
+	}
+	derived := signer.PublicKey()
+
+	publicKey, err := ioutil.ReadFile(publicKeyPath)
+	if os.IsNotExist(err) {
+		log.Warnf("KDK ssh public key %s not found.  Regenerating it from the private key", publicKeyPath)
+		return ssh.WriteKeyToFile(gossh.MarshalAuthorizedKey(derived), publicKeyPath)
+	} else if err != nil {
+		return err
+	}
+
+	guidance := fmt.Sprintf("Remove %s to regenerate it from the private key, or remove both to generate a new keypair", publicKeyPath)
+	parsed, _, _, _, err := gossh.ParseAuthorizedKey(publicKey)
+	if err != nil {
+		return wrapError(ErrKeyPairMismatch, fmt.Errorf("invalid KDK ssh public key %s: %v.  %s", publicKeyPath, err, guidance))
+	}
+	if !bytes.Equal(parsed.Marshal(), derived.Marshal()) {
+		return wrapError(ErrKeyPairMismatch, fmt.Errorf("%s (%s) does not match %s (%s).  %s", publicKeyPath,
+			gossh.FingerprintSHA256(parsed), privateKeyPath, gossh.FingerprintSHA256(derived), guidance))
+	}
+	return nil
+}
+
+// The KDK ssh public key, in authorized_keys format
+func (c *KdkEnvConfig) PublicKey() (string, error) {
+	publicKey, err := c.readPublicKey()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(publicKey)), nil
+}
+
+// OpenSSH SHA256 fingerprint of the KDK ssh public key (e.g. SHA256:x2w...), as `ssh-keygen -l` prints it
+func (c *KdkEnvConfig) Fingerprint() (string, error) {
+	publicKey, err := c.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	parsed, _, _, _, err := gossh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid KDK ssh public key %s: %v", c.PublicKeyPath(), err)
+	}
+	return gossh.FingerprintSHA256(parsed), nil
+}
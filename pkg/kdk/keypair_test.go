@@ -0,0 +1,127 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cisco-sso/kdk/pkg/ssh"
+)
+
+// Write a new keypair to dir, returning the private and public key paths
+func writeTestKeyPair(t *testing.T, dir, name string) (string, string) {
+	privateKey, err := ssh.GeneratePrivateKey(2048)
+	if err != nil {
+		t.Log("Failed to generate private key.", err)
+		t.FailNow()
+	}
+	publicKey, err := ssh.GeneratePublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Log("Failed to generate public key.", err)
+		t.FailNow()
+	}
+	privatePath := filepath.Join(dir, name)
+	publicPath := privatePath + ".pub"
+	if err := ssh.WriteKeyToFile(ssh.EncodePrivateKey(privateKey), privatePath); err != nil {
+		t.Log("Failed to write private key.", err)
+		t.FailNow()
+	}
+	if err := ssh.WriteKeyToFile(publicKey, publicPath); err != nil {
+		t.Log("Failed to write public key.", err)
+		t.FailNow()
+	}
+	return privatePath, publicPath
+}
+
+func TestCheckKeyPairMatching(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kdk-keypair")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath, publicPath := writeTestKeyPair(t, dir, "id_rsa")
+	if err := checkKeyPair(privatePath, publicPath); err != nil {
+		t.Log("checkKeyPair rejected a matching keypair.", err)
+		t.FailNow()
+	}
+}
+
+func TestCheckKeyPairMissingPublicKey(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kdk-keypair")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath, publicPath := writeTestKeyPair(t, dir, "id_rsa")
+	original, _ := ioutil.ReadFile(publicPath)
+	os.Remove(publicPath)
+
+	if err := checkKeyPair(privatePath, publicPath); err != nil {
+		t.Log("checkKeyPair failed to regenerate a missing public key.", err)
+		t.FailNow()
+	}
+	regenerated, err := ioutil.ReadFile(publicPath)
+	if err != nil {
+		t.Log("checkKeyPair did not write the public key.", err)
+		t.FailNow()
+	}
+	if string(regenerated) != string(original) {
+		t.Logf("Regenerated public key [%s] differs from the original [%s].", regenerated, original)
+		t.FailNow()
+	}
+}
+
+func TestCheckKeyPairMismatch(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "kdk-keypair")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	privatePath, publicPath := writeTestKeyPair(t, dir, "id_rsa")
+	_, otherPublicPath := writeTestKeyPair(t, dir, "other")
+	other, _ := ioutil.ReadFile(otherPublicPath)
+	if err := ioutil.WriteFile(publicPath, other, 0600); err != nil {
+		t.Log("Failed to write mismatched public key.", err)
+		t.FailNow()
+	}
+
+	err = checkKeyPair(privatePath, publicPath)
+	if !errors.Is(err, ErrKeyPairMismatch) {
+		t.Log("checkKeyPair accepted a mismatched keypair.", err)
+		t.FailNow()
+	}
+
+	if err := ioutil.WriteFile(publicPath, []byte("not a key\n"), 0600); err != nil {
+		t.Log("Failed to write invalid public key.", err)
+		t.FailNow()
+	}
+	if err := checkKeyPair(privatePath, publicPath); !errors.Is(err, ErrKeyPairMismatch) {
+		t.Log("checkKeyPair accepted an invalid public key.", err)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// A KeyProvider sources the KDK ssh keypair, selected by AppConfig.KeyProvider.  Integrators may back
+// it with a secrets manager (e.g. Vault or SSM) and register it with RegisterKeyProvider.
+//
+// Contract:
+//   - EnsureKey makes the keypair available.  It runs at `kdk init`, and at every start for providers
+//     other than the default "file".  It must write the public key, in authorized_keys format, to
+//     c.PublicKeyPath(): the KDK container is created with that file mounted.  It should not write
+//     the private key to disk.
+//   - Signers returns the signers kdk authenticates its ssh sessions with.  Commands which shell out
+//     to ssh (e.g. `kdk ssh` with an ssh client) need the private key from disk or ssh-agent instead.
+type KeyProvider interface {
+	EnsureKey(c *KdkEnvConfig) error
+	Signers(c *KdkEnvConfig) ([]gossh.Signer, error)
+}
+
+// Default KeyProvider
+const defaultKeyProvider = "file"
+
+var keyProviders = map[string]KeyProvider{
+	defaultKeyProvider: fileKeyProvider{},
+	"agent":            agentKeyProvider{},
+}
+
+// Register a KeyProvider under name, for use as AppConfig.KeyProvider.  Call it from an init function.
+func RegisterKeyProvider(name string, provider KeyProvider) {
+	keyProviders[name] = provider
+}
+
+// The configured KeyProvider.  Empty selects the default
+func (c *KdkEnvConfig) keyProvider() (KeyProvider, error) {
+	name := c.ConfigFile.AppConfig.KeyProvider
+	if name == "" {
+		name = defaultKeyProvider
+	}
+	provider, ok := keyProviders[name]
+	if !ok {
+		return nil, validateKeyProvider(name)
+	}
+	return provider, nil
+}
+
+// A KeyProvider, when provided, must be registered
+func validateKeyProvider(name string) error {
+	if _, ok := keyProviders[name]; name == "" || ok {
+		return nil
+	}
+	var names []string
+	for known := range keyProviders {
+		names = append(names, known)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("invalid KeyProvider [%s]: must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Make the KDK keypair available through the configured KeyProvider
+func (c *KdkEnvConfig) EnsureKey() error {
+	provider, err := c.keyProvider()
+	if err != nil {
+		return err
+	}
+	return provider.EnsureKey(c)
+}
+
+// Fetch the KDK keypair at start, from a KeyProvider other than the default "file"
+func (c *KdkEnvConfig) FetchKey() error {
+	// an in-memory KDK is given its public key
+	if name := c.ConfigFile.AppConfig.KeyProvider; c.InMemory || name == "" || name == defaultKeyProvider {
+		return nil
+	}
+	return c.EnsureKey()
+}
+
+// The keypair under ~/.kdk/ssh, generated by `kdk init`
+type fileKeyProvider struct{}
+
+func (fileKeyProvider) EnsureKey(c *KdkEnvConfig) error {
+	return c.CreateKdkSshKeyPair()
+}
+
+// Configs written before KeyProvider hold an ssh-agent key as a public key without a private key
+func (fileKeyProvider) Signers(c *KdkEnvConfig) ([]gossh.Signer, error) {
+	key, err := ioutil.ReadFile(c.PrivateKeyPath())
+	if os.IsNotExist(err) {
+		return agentKeyProvider{}.Signers(c)
+	} else if err != nil {
+		return nil, err
+	}
+	signer, err := gossh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return []gossh.Signer{signer}, nil
+}
+
+// A key held by ssh-agent, selected by `kdk init --ssh-agent`
+type agentKeyProvider struct{}
+
+func (agentKeyProvider) EnsureKey(c *KdkEnvConfig) error {
+	if _, err := os.Stat(c.PublicKeyPath()); err == nil {
+		return nil
+	}
+	return c.CreateKdkSshKeyFromAgent("")
+}
+
+func (agentKeyProvider) Signers(c *KdkEnvConfig) ([]gossh.Signer, error) {
+	sshAgent, err := sshAgent()
+	if err != nil {
+		return nil, err
+	}
+	return sshAgent.Signers()
+}
@@ -0,0 +1,175 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+// The parts of a kubeconfig needed to filter it by context.  Clusters and users are kept verbatim.
+type kubeconfig struct {
+	APIVersion     string                   `json:"apiVersion,omitempty"`
+	Kind           string                   `json:"kind,omitempty"`
+	Preferences    interface{}              `json:"preferences,omitempty"`
+	CurrentContext string                   `json:"current-context,omitempty"`
+	Clusters       []map[string]interface{} `json:"clusters"`
+	Contexts       []kubeContext            `json:"contexts"`
+	Users          []map[string]interface{} `json:"users"`
+}
+
+type kubeContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"context"`
+}
+
+// Filename of the filtered kubeconfig within the KDK config dir
+const filteredKubeconfig = "kubeconfig"
+
+func readKubeconfig(kubeconfigPath string) (*kubeconfig, error) {
+	data, err := ioutil.ReadFile(kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubeconfig [%s]: %v", kubeconfigPath, err)
+	}
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid Kubeconfig [%s]: %v", kubeconfigPath, err)
+	}
+	return &config, nil
+}
+
+// Reduce config to contexts, and the clusters and users they reference.  Every context must exist.
+func filterKubeconfig(config *kubeconfig, contexts []string) (*kubeconfig, error) {
+	byName := map[string]kubeContext{}
+	for _, context := range config.Contexts {
+		byName[context.Name] = context
+	}
+	filtered := &kubeconfig{APIVersion: config.APIVersion, Kind: config.Kind, Preferences: config.Preferences}
+	clusters, users := map[string]bool{}, map[string]bool{}
+	for _, name := range contexts {
+		context, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid KubeContexts: context [%s] not found in kubeconfig", name)
+		}
+		filtered.Contexts = append(filtered.Contexts, context)
+		clusters[context.Context.Cluster] = true
+		users[context.Context.User] = true
+		if name == config.CurrentContext {
+			filtered.CurrentContext = name
+		}
+	}
+	if filtered.CurrentContext == "" && len(filtered.Contexts) > 0 {
+		filtered.CurrentContext = filtered.Contexts[0].Name
+	}
+	for _, cluster := range config.Clusters {
+		if name, _ := cluster["name"].(string); clusters[name] {
+			filtered.Clusters = append(filtered.Clusters, cluster)
+		}
+	}
+	for _, user := range config.Users {
+		if name, _ := user["name"].(string); users[name] {
+			filtered.Users = append(filtered.Users, user)
+		}
+	}
+	return filtered, nil
+}
+
+// Validate that the kubeconfig parses, and has each of contexts
+func validateKubeconfig(kubeconfigPath string, contexts []string) error {
+	if kubeconfigPath == "" {
+		if len(contexts) > 0 {
+			return fmt.Errorf("invalid KubeContexts: requires Kubeconfig")
+		}
+		return nil
+	}
+	config, err := readKubeconfig(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	_, err = filterKubeconfig(config, contexts)
+	return err
+}
+
+// Host source of the kubeconfig mount: the kubeconfig itself, or when filtered by context
+// a copy in the KDK config dir, (re)written from the current host kubeconfig
+func (c *KdkEnvConfig) writeKubeconfig() (string, error) {
+	appConfig := c.ConfigFile.AppConfig
+	if len(appConfig.KubeContexts) == 0 {
+		return appConfig.Kubeconfig, nil
+	}
+	config, err := readKubeconfig(appConfig.Kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	filtered, err := filterKubeconfig(config, appConfig.KubeContexts)
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(filtered)
+	if err != nil {
+		return "", err
+	}
+	filteredPath := filepath.Join(c.ConfigDir(), filteredKubeconfig)
+	if err := os.MkdirAll(c.ConfigDir(), 0700); err != nil {
+		return "", err
+	}
+	// The kubeconfig holds credentials
+	if err := ioutil.WriteFile(filteredPath, data, 0600); err != nil {
+		return "", err
+	}
+	return filteredPath, nil
+}
+
+// Read-only mount of the kubeconfig at ~/.kube/config in the KDK, if configured
+func (c *KdkEnvConfig) kubeconfigMount() (mount.Mount, bool, error) {
+	if c.ConfigFile.AppConfig.Kubeconfig == "" {
+		return mount.Mount{}, false, nil
+	}
+	source, err := c.writeKubeconfig()
+	if err != nil {
+		return mount.Mount{}, false, err
+	}
+	return mount.Mount{Type: mount.TypeBind, Source: source, Target: path.Join(c.ContainerHome(), ".kube", "config"),
+		ReadOnly: true}, true, nil
+}
+
+// Rewrite the filtered kubeconfig (e.g. after host credentials were refreshed) before the KDK starts.
+// The host kubeconfig is checked here rather than on load, so that a rotated or removed kubeconfig
+// only warns instead of failing every command.
+func (c *KdkEnvConfig) refreshKubeconfig() {
+	if c.InMemory || c.ConfigFile.AppConfig.Kubeconfig == "" {
+		return
+	}
+	if len(c.ConfigFile.AppConfig.KubeContexts) == 0 {
+		if err := validateKubeconfig(c.ConfigFile.AppConfig.Kubeconfig, nil); err != nil {
+			log.WithField("error", err).Warn("Failed to read the host kubeconfig.  kubectl in the KDK may fail")
+		}
+		return
+	}
+	if _, err := c.writeKubeconfig(); err != nil {
+		log.WithField("error", err).Warn("Failed to refresh the filtered kubeconfig")
+	}
+}
@@ -15,13 +15,24 @@
 package kdk
 
 import (
+	"path"
+
 	log "github.com/sirupsen/logrus"
 )
 
 func Kubesync(cfg KdkEnvConfig) {
 
 	// If KDK container is not running, start it and provision KDK user.
-	cfg.Start()
+	if err := cfg.Start(); err != nil {
+		log.WithField("error", err).Fatal("Failed to start KDK container")
+	}
+
+	// A read-only host ~/.kube mount already provides the KUBECONFIG, and can't be written to
+	for _, m := range cfg.ConfigFile.HostConfig.Mounts {
+		if m.Target == path.Join(cfg.ContainerHome(), ".kube") && m.ReadOnly {
+			log.Fatal("Host ~/.kube is mounted read-only into the KDK.  Nothing to sync.")
+		}
+	}
 
 	kubeconfigHostPath := cfg.Home() + "/.kube/config"
 	kubeconfigKDKPath := ".kube/docker-for-desktop.example.org"
@@ -0,0 +1,87 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Parse `key=value` label arguments.  Only the first `=` separates the key, so values are kept verbatim.
+func ParseLabels(args []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid label [%s]: must be key=value", arg)
+		}
+		labels[kv[0]] = kv[1]
+	}
+	return labels, nil
+}
+
+// Merge user labels into the kdk managed labels.  Keys and values are copied verbatim,
+// but user labels may not override the labels kdk manages.
+func mergeLabels(kdkLabels map[string]string, userLabels map[string]string) map[string]string {
+	labels := map[string]string{}
+	for key, value := range userLabels {
+		labels[key] = value
+	}
+	for key, value := range kdkLabels {
+		if userValue, ok := userLabels[key]; ok && userValue != value {
+			log.Warnf("Ignoring label [%s]: reserved for use by kdk", key)
+		}
+		labels[key] = value
+	}
+	return labels
+}
+
+// Label of the version of kdk which created the KDK container
+const versionLabel = "kdk"
+
+// A kdk managed label of the KDK container which no longer matches the running kdk
+type LabelDrift struct {
+	Label     string
+	Container string // value on the container
+	Current   string // value kdk would set now
+}
+
+func (d LabelDrift) String() string {
+	return fmt.Sprintf("%s=%s (now %s)", d.Label, d.Container, d.Current)
+}
+
+// Compare the kdk managed labels of the KDK container to the running kdk.  A container created by
+// an older kdk may lack bootstrap and config features of the current one, and should be recreated.
+// Development builds (Version "undefined") report no version drift.
+func (c *KdkEnvConfig) ReconcileLabels() ([]LabelDrift, error) {
+	inspect, err := c.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	var labels map[string]string
+	if inspect.Config != nil {
+		labels = inspect.Config.Labels
+	}
+	var drift []LabelDrift
+	if Version != "undefined" && labels[versionLabel] != Version {
+		drift = append(drift, LabelDrift{Label: versionLabel, Container: labels[versionLabel], Current: Version})
+	}
+	if user, ok := labels[userLabel]; ok && user != c.User() {
+		drift = append(drift, LabelDrift{Label: userLabel, Container: user, Current: c.User()})
+	}
+	return drift, nil
+}
@@ -0,0 +1,82 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/ghodss/yaml"
+)
+
+func TestLabelsRoundTrip(t *testing.T) {
+
+	userLabels, err := ParseLabels([]string{
+		"traefik.enable=true",
+		"traefik.http.routers.kdk.rule=Host(`kdk.localhost`) && Query(`a=b`)",
+		"traefik.http.services.kdk.loadbalancer.server.port=8080",
+		"com.example.empty=",
+	})
+	if err != nil {
+		t.Log("ParseLabels failed to parse valid labels.", err)
+		t.FailNow()
+	}
+	if userLabels["traefik.http.routers.kdk.rule"] != "Host(`kdk.localhost`) && Query(`a=b`)" {
+		t.Log("ParseLabels mangled a value containing '='.")
+		t.FailNow()
+	}
+
+	labels := mergeLabels(map[string]string{"kdk": "1.0.0"}, userLabels)
+
+	// Round trip the labels through the config file format
+	in := configFile{
+		AppConfig:       AppConfig{Labels: userLabels},
+		ContainerConfig: &container.Config{Labels: labels},
+	}
+	y, err := yaml.Marshal(&in)
+	if err != nil {
+		t.Log("Failed to marshal config.", err)
+		t.FailNow()
+	}
+	var out configFile
+	if err := yaml.Unmarshal(y, &out); err != nil {
+		t.Log("Failed to unmarshal config.", err)
+		t.FailNow()
+	}
+
+	if !reflect.DeepEqual(out.AppConfig.Labels, userLabels) {
+		t.Logf("AppConfig labels changed in round trip: %v", out.AppConfig.Labels)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(out.ContainerConfig.Labels, labels) || out.ContainerConfig.Labels["kdk"] != "1.0.0" {
+		t.Logf("ContainerConfig labels changed in round trip: %v", out.ContainerConfig.Labels)
+		t.FailNow()
+	}
+}
+
+func TestLabelsReserved(t *testing.T) {
+
+	labels := mergeLabels(map[string]string{"kdk": "1.0.0"}, map[string]string{"kdk": "evil"})
+	if labels["kdk"] != "1.0.0" {
+		t.Log("User labels overrode a kdk managed label.")
+		t.FailNow()
+	}
+
+	if _, err := ParseLabels([]string{"novalue"}); err == nil {
+		t.Log("ParseLabels accepted a label without '='.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Options for Logs
+type LogsOptions struct {
+	Follow    bool   // stream new lines until interrupted
+	Tail      string // number of trailing lines, or "all"
+	Filter    string // regular expression (a plain substring also works) lines must match
+	Bootstrap bool   // show the KDK bootstrap log instead of the container log
+}
+
+// Write the KDK container log to w, only the lines matching opts.Filter if set.  With
+// opts.Bootstrap the KDK bootstrap log is shown instead, e.g. to debug authorized_keys failures.
+func (c *KdkEnvConfig) Logs(w io.Writer, opts LogsOptions) error {
+	var filter *regexp.Regexp
+	if opts.Filter != "" {
+		var err error
+		if filter, err = regexp.Compile(opts.Filter); err != nil {
+			return fmt.Errorf("invalid log filter [%s]: %v", opts.Filter, err)
+		}
+	}
+
+	if opts.Bootstrap {
+		if opts.Follow {
+			return errors.New("following the bootstrap log is not supported")
+		}
+		args := []string{"cat", bootstrapLog}
+		if opts.Tail != "" && opts.Tail != "all" {
+			args = []string{"tail", "-n", opts.Tail, bootstrapLog}
+		}
+		out, exitCode, err := c.containerExec("", args...)
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("failed to read bootstrap log %s: %s", bootstrapLog, strings.TrimSpace(out))
+		}
+		return filterLines(w, strings.NewReader(out), filter)
+	}
+
+	inspect, err := c.DockerClient.ContainerInspect(c.Ctx, c.ContainerName())
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	logs, err := c.DockerClient.ContainerLogs(c.Ctx, c.ContainerName(), types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	defer logs.Close()
+
+	// A tty container log is a raw stream.  Without a tty (AppConfig.Tty false), docker multiplexes
+	// stdout and stderr into one stream with frame headers, which must be demultiplexed
+	if inspect.Config != nil && inspect.Config.Tty {
+		return filterLines(w, logs, filter)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+	return filterLines(w, pr, filter)
+}
+
+// Copy the lines of r matching filter (all lines when nil) to w
+func filterLines(w io.Writer, r io.Reader, filter *regexp.Regexp) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" && (filter == nil || filter.MatchString(line)) {
+			if _, werr := io.WriteString(w, line); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
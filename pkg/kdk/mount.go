@@ -0,0 +1,149 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Add a mount to the stored KDK config.  Docker cannot add mounts to an existing container,
+// so recreate reports whether the container must be recreated for the change to apply.
+func (c *KdkEnvConfig) AddMount(m mount.Mount, relabel string) (recreate bool, err error) {
+	if c.ConfigFile.ContainerConfig == nil || c.ConfigFile.HostConfig == nil {
+		return false, fmt.Errorf("%w: run `kdk init` first", ErrConfigNotFound)
+	}
+	if err := validateRelabel(relabel); err != nil {
+		return false, err
+	}
+	for _, existing := range c.ConfigFile.HostConfig.Mounts {
+		if existing.Target == m.Target {
+			return false, fmt.Errorf("mount target [%s] already exists", m.Target)
+		}
+	}
+	for _, existing := range c.ConfigFile.HostConfig.Binds {
+		if bindTarget(existing) == m.Target {
+			return false, fmt.Errorf("mount target [%s] already exists", m.Target)
+		}
+	}
+
+	if bind, ok := c.relabeledBind(m, relabel); ok {
+		c.ConfigFile.HostConfig.Binds = append(c.ConfigFile.HostConfig.Binds, bind)
+	} else {
+		c.ConfigFile.HostConfig.Mounts = append(c.ConfigFile.HostConfig.Mounts, m)
+	}
+	if c.ConfigFile.ContainerConfig.Volumes == nil {
+		c.ConfigFile.ContainerConfig.Volumes = map[string]struct{}{}
+	}
+	c.ConfigFile.ContainerConfig.Volumes[m.Target] = struct{}{}
+
+	if err := c.SaveKdkConfig(); err != nil {
+		return false, err
+	}
+	return c.needsRecreate()
+}
+
+// Remove the mount with the given container target from the stored KDK config.
+// recreate reports whether the container must be recreated for the change to apply.
+func (c *KdkEnvConfig) RemoveMount(target string) (recreate bool, err error) {
+	if c.ConfigFile.ContainerConfig == nil || c.ConfigFile.HostConfig == nil {
+		return false, fmt.Errorf("%w: run `kdk init` first", ErrConfigNotFound)
+	}
+
+	var mounts []mount.Mount
+	for _, existing := range c.ConfigFile.HostConfig.Mounts {
+		if existing.Target != target {
+			mounts = append(mounts, existing)
+		}
+	}
+	var binds []string
+	for _, existing := range c.ConfigFile.HostConfig.Binds {
+		if bindTarget(existing) != target {
+			binds = append(binds, existing)
+		}
+	}
+	if len(mounts) == len(c.ConfigFile.HostConfig.Mounts) && len(binds) == len(c.ConfigFile.HostConfig.Binds) {
+		return false, fmt.Errorf("mount target [%s] not found", target)
+	}
+
+	c.ConfigFile.HostConfig.Mounts = mounts
+	c.ConfigFile.HostConfig.Binds = binds
+	delete(c.ConfigFile.ContainerConfig.Volumes, target)
+
+	if err := c.SaveKdkConfig(); err != nil {
+		return false, err
+	}
+	return c.needsRecreate()
+}
+
+// An existing container keeps the mounts it was created with
+func (c *KdkEnvConfig) needsRecreate() (bool, error) {
+	container, err := c.findContainer()
+	if err != nil {
+		return false, err
+	}
+	return container != nil, nil
+}
+
+// Chown the writable bind mount targets under the KDK user home, and any parents docker created
+// for them, to the KDK user.  Docker creates missing targets owned by root.  The chown is not
+// recursive, so host file ownership within the mounts is untouched.
+func (c *KdkEnvConfig) chownMounts() error {
+	if c.ConfigFile.HostConfig == nil {
+		return nil
+	}
+	home := c.ContainerHome()
+	var targets []string
+	for _, m := range c.ConfigFile.HostConfig.Mounts {
+		if m.Type == mount.TypeBind && !m.ReadOnly && strings.HasPrefix(m.Target, home+"/") {
+			targets = append(targets, m.Target)
+		}
+	}
+	return c.chownTargets(targets)
+}
+
+// Chown targets and their parents, up to the KDK user home or /, to the KDK user.  Not recursive.
+func (c *KdkEnvConfig) chownTargets(targets []string) error {
+	home := c.ContainerHome()
+	dirs := map[string]bool{}
+	for _, target := range targets {
+		for dir := path.Clean(target); dir != home && dir != "/"; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	args := []string{"chown", c.User() + ":"}
+	for dir := range dirs {
+		args = append(args, dir)
+	}
+	sort.Strings(args[2:])
+	out, exitCode, err := c.containerExec("", args...)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to chown mount targets (exit %d): %s", exitCode, out)
+	}
+	log.Debugf("Chowned mount targets %v to [%s]", args[2:], c.User())
+	return nil
+}
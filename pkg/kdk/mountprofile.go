@@ -0,0 +1,76 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Bind mount consistency of each MountProfile on macOS, where Docker Desktop shares host
+// files with the VM.  "fast" lets the container's view lag the host, "safe" keeps them in sync.
+var mountProfiles = map[string]mount.Consistency{
+	"fast": mount.ConsistencyDelegated,
+	"safe": mount.ConsistencyFull,
+}
+
+// A MountProfile must be "", "fast", or "safe"
+func validateMountProfile(profile string) error {
+	if _, ok := mountProfiles[profile]; profile != "" && !ok {
+		return fmt.Errorf("invalid MountProfile [%s]: must be fast or safe", profile)
+	}
+	return nil
+}
+
+// Apply the MountProfile consistency to every bind mount of hostConfig, overriding per mount
+// consistency.  Consistency only matters to Docker Desktop for Mac, so this is a no-op elsewhere.
+func applyMountProfile(hostConfig *container.HostConfig, profile string) {
+	consistency, ok := mountProfiles[profile]
+	if !ok || runtime.GOOS != "darwin" {
+		return
+	}
+	for i := range hostConfig.Mounts {
+		if hostConfig.Mounts[i].Type == mount.TypeBind {
+			hostConfig.Mounts[i].Consistency = consistency
+		}
+	}
+	for i, bind := range hostConfig.Binds {
+		hostConfig.Binds[i] = bindWithConsistency(bind, consistency)
+	}
+}
+
+// Replace the consistency option of a HostConfig.Binds entry, e.g. /src:/dst:ro,z with
+// /src:/dst:ro,z,delegated.  Binds without options are left as is, since a Windows source
+// (C:\...) makes the parts ambiguous.
+func bindWithConsistency(bind string, consistency mount.Consistency) string {
+	parts := strings.Split(bind, ":")
+	if len(parts) < 3 {
+		return bind
+	}
+	var options []string
+	for _, option := range strings.Split(parts[len(parts)-1], ",") {
+		switch mount.Consistency(option) {
+		case mount.ConsistencyFull, mount.ConsistencyCached, mount.ConsistencyDelegated:
+			continue
+		}
+		options = append(options, option)
+	}
+	parts[len(parts)-1] = strings.Join(append(options, string(consistency)), ",")
+	return strings.Join(parts, ":")
+}
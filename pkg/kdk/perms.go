@@ -0,0 +1,99 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Permissions of the directories and config files kdk creates under ~/.kdk.  The defaults keep
+// them private.  Teams sharing config dirs with a service account group may set e.g. 0750 and
+// 0640 (KDK_DIR_MODE, KDK_FILE_MODE).  The private key is 0600 whatever the policy.
+var (
+	DirMode  os.FileMode = 0700
+	FileMode os.FileMode = 0600
+)
+
+// Octal file mode from environment variable env, or def if unset or invalid
+func EnvFileMode(env string, def os.FileMode) os.FileMode {
+	value := os.Getenv(env)
+	if value == "" {
+		return def
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode&^0777 != 0 {
+		log.Warnf("Ignoring invalid %s [%s]: must be an octal mode, e.g. 0750", env, value)
+		return def
+	}
+	return os.FileMode(mode)
+}
+
+// The owner must keep full access to directories and read-write access to files, and nothing may
+// be world writable
+func validatePermissions(dirMode, fileMode os.FileMode) error {
+	if dirMode&0700 != 0700 || dirMode&0002 != 0 {
+		return fmt.Errorf("invalid DirMode [%#o]: must be owner rwx and not world writable", dirMode)
+	}
+	if fileMode&0600 != 0600 || fileMode&0002 != 0 || fileMode&0111 != 0 {
+		return fmt.Errorf("invalid FileMode [%#o]: must be owner rw, not executable, and not world writable", fileMode)
+	}
+	return nil
+}
+
+// Create dir and its parents with DirMode.  The mode is set explicitly, since the umask would
+// otherwise narrow it.
+func mkdirPolicy(dir string) error {
+	if err := validatePermissions(DirMode, FileMode); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return err
+	}
+	return os.Chmod(dir, DirMode)
+}
+
+// Write a config file with FileMode
+func writeFilePolicy(file string, data []byte) error {
+	if err := validatePermissions(DirMode, FileMode); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, data, FileMode); err != nil {
+		return err
+	}
+	return os.Chmod(file, FileMode)
+}
+
+// Restrict the private key to its owner, whatever the permission policy, since ssh refuses
+// keys others can read.  Windows does not map group and world permissions onto file modes.
+func ensurePrivateKeyMode(privateKeyPath string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(privateKeyPath)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0077 == 0 {
+		return nil
+	}
+	log.Warnf("KDK ssh private key %s is accessible to other users [%#o].  Restricting it to 0600", privateKeyPath, info.Mode().Perm())
+	return os.Chmod(privateKeyPath, 0600)
+}
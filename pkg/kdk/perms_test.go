@@ -0,0 +1,79 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestValidatePermissions(t *testing.T) {
+
+	valid := [][2]os.FileMode{{0700, 0600}, {0750, 0640}, {0770, 0660}}
+	for _, modes := range valid {
+		if err := validatePermissions(modes[0], modes[1]); err != nil {
+			t.Logf("Expected modes %#o, %#o to be valid. %v", modes[0], modes[1], err)
+			t.FailNow()
+		}
+	}
+	invalid := [][2]os.FileMode{{0500, 0600}, {0777, 0600}, {0700, 0400}, {0700, 0666}, {0700, 0700}}
+	for _, modes := range invalid {
+		if err := validatePermissions(modes[0], modes[1]); err == nil {
+			t.Logf("Expected modes %#o, %#o to be invalid", modes[0], modes[1])
+			t.FailNow()
+		}
+	}
+}
+
+func TestEnsurePrivateKeyMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes do not apply on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "kdk-perms")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	privateKeyPath := filepath.Join(dir, "id_rsa")
+	if err := ioutil.WriteFile(privateKeyPath, []byte("key"), 0600); err != nil {
+		t.Log("Failed to write private key.", err)
+		t.FailNow()
+	}
+	// e.g. a group readable policy applied by hand
+	if err := os.Chmod(privateKeyPath, 0640); err != nil {
+		t.Log("Failed to chmod private key.", err)
+		t.FailNow()
+	}
+
+	if err := ensurePrivateKeyMode(privateKeyPath); err != nil {
+		t.Log("Expected the private key mode to be restricted.", err)
+		t.FailNow()
+	}
+	info, err := os.Stat(privateKeyPath)
+	if err != nil {
+		t.Log("Failed to stat private key.", err)
+		t.FailNow()
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Logf("Expected private key mode 0600, got %#o", info.Mode().Perm())
+		t.FailNow()
+	}
+}
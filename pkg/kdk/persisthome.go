@@ -0,0 +1,68 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+)
+
+// Docker volume names
+var volumeName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
+// PersistHome is a host path when it looks like one, otherwise a named volume
+func isHostPath(source string) bool {
+	return strings.ContainsAny(source, `/\`) || strings.HasPrefix(source, "~") || strings.HasPrefix(source, ".")
+}
+
+// A PersistHome, when provided, must be a host path or a valid docker volume name
+func validatePersistHome(source string) error {
+	if source == "" || isHostPath(source) || volumeName.MatchString(source) {
+		return nil
+	}
+	return fmt.Errorf("invalid PersistHome [%s]: must be a host path or a docker volume name", source)
+}
+
+// Mount of the persisted KDK user home, and whether one is configured.  A host directory is created
+// if missing.  The bootstrap seeds an empty home from /etc/skel.
+func (c *KdkEnvConfig) persistHomeMount() (mount.Mount, bool, error) {
+	source := c.ConfigFile.AppConfig.PersistHome
+	if source == "" {
+		return mount.Mount{}, false, nil
+	}
+	if !isHostPath(source) {
+		return mount.Mount{Type: mount.TypeVolume, Source: source, Target: c.ContainerHome()}, true, nil
+	}
+
+	source, err := homedir.Expand(source)
+	if err != nil {
+		return mount.Mount{}, false, err
+	}
+	if source, err = filepath.Abs(source); err != nil {
+		return mount.Mount{}, false, err
+	}
+	if err := os.MkdirAll(source, 0700); err != nil {
+		return mount.Mount{}, false, err
+	}
+	log.Infof("Persisting the KDK home in [%s]", source)
+	return mount.Mount{Type: mount.TypeBind, Source: source, Target: c.ContainerHome(), Consistency: mount.ConsistencyCached}, true, nil
+}
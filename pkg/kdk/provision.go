@@ -15,18 +15,27 @@
 package kdk
 
 import (
-	"github.com/codeskyblue/go-sh"
+	"fmt"
+	"strings"
+
 	log "github.com/sirupsen/logrus"
 )
 
 func Provision(cfg KdkEnvConfig) error {
-	// TODO (rluckie): replace sh docker sdk
 	log.Info("Starting KDK user provisioning. This may take a moment.  Hang tight...")
-	if _, err := sh.Command("docker", "exec", cfg.ConfigFile.AppConfig.Name, "/usr/local/bin/provision-user").Output(); err != nil {
-		log.WithField("error", err).Fatal("Failed to provision KDK user.")
+	shell, err := cfg.loginShell()
+	if err != nil {
+		log.WithField("error", err).Error("Failed to check the KDK shell.")
 		return err
-	} else {
-		log.Info("Completed KDK user provisioning.")
-		return nil
 	}
+	// through the docker API, so that the exec reaches the daemon of the docker context in use
+	out, exitCode, err := cfg.containerExec("", "env", "KDK_SHELL="+shell, "/usr/local/bin/provision-user")
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("provision-user failed (exit %d)", exitCode)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to provision KDK user: %w.  Output:\n%s", err, strings.TrimSpace(out))
+	}
+	log.Info("Completed KDK user provisioning.")
+	return nil
 }
@@ -15,82 +15,158 @@
 package kdk
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/cisco-sso/kdk/pkg/prompt"
-	"github.com/cisco-sso/kdk/pkg/utils"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/go-units"
 	log "github.com/sirupsen/logrus"
 )
 
-func Prune(cfg KdkEnvConfig) error {
-	log.Info("Starting Prune...")
+// A local image of a KDK image repository that no KDK config or container references
+type StaleImage struct {
+	ID string
+	// Tags of KDK repositories to remove.  The image is deleted once its last tag is removed.
+	// Untagged (dangling) images have none, and are removed by ID.
+	RepoTags []string
+	Size     int64
+}
 
-	var (
-		imageIds                 []string
-		runningContainerImageIds []string
-		staleImageIds            []string
-	)
+// The name, or short ID of an untagged image
+func (s StaleImage) String() string {
+	if len(s.RepoTags) > 0 {
+		return strings.Join(s.RepoTags, ", ")
+	}
+	return strings.TrimPrefix(s.ID, "sha256:")[:12]
+}
 
-	// Get containers
-	containers, err := cfg.DockerClient.ContainerList(cfg.Ctx, types.ContainerListOptions{})
-	if err != nil {
-		log.WithField("error", err).Fatal("Failed to list docker containers")
+// Split an image reference into repository and tag or digest, e.g. "localhost:5000/kdk:latest"
+func splitImageRef(ref string) (repository, tag string) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		return ref[:i], ref[i+1:]
 	}
-	// Get images
-	images, err := cfg.DockerClient.ImageList(cfg.Ctx, types.ImageListOptions{})
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
+
+// List the local images of any configured KDK ImageRepository that are no longer referenced by a
+// KDK config, nor used by any container.  Only images of the ImageRepository values of the KDK
+// configs under ~/.kdk are considered, so other images are never listed.
+func (c *KdkEnvConfig) StaleImages() ([]StaleImage, error) {
+	repositories := map[string]bool{}
+	referenced := map[string]bool{}
+	names, err := c.ListKdkConfigs()
 	if err != nil {
-		log.WithField("error", err).Fatal("Failed to list docker images")
+		return nil, err
+	}
+	for _, name := range names {
+		env, err := c.loadEnv(name)
+		if err != nil {
+			log.WithField("error", err).Warnf("Failed to load KDK environment [%s].  Its images are not considered", name)
+			continue
+		}
+		repositories[env.ConfigFile.AppConfig.ImageRepository] = true
+		referenced[env.ImageCoordinates()] = true
+	}
+	if c.ConfigFile.AppConfig.ImageRepository != "" {
+		repositories[c.ConfigFile.AppConfig.ImageRepository] = true
+		referenced[c.ImageCoordinates()] = true
 	}
 
-	// Iterate through containers and track running container imageIds
+	// Images of any container, stopped or not, are in use
+	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+	inUse := map[string]bool{}
 	for _, container := range containers {
-		if strings.Contains(container.Status, "Up") {
-			runningContainerImageIds = append(runningContainerImageIds, container.ImageID)
-		}
+		inUse[container.ImageID] = true
 	}
 
-	// Iterate through images and track images that have a `kdk` label key
+	images, err := c.DockerClient.ImageList(c.Ctx, types.ImageListOptions{})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+	var stale []StaleImage
 	for _, image := range images {
-		for key := range image.Labels {
-			if key == "kdk" {
-				imageIds = append(imageIds, image.ID)
-				break
+		if inUse[image.ID] {
+			continue
+		}
+		var kdkTags []string
+		isReferenced, isKdk := false, false
+		for _, ref := range image.RepoTags {
+			if repository, _ := splitImageRef(ref); repositories[repository] {
+				kdkTags = append(kdkTags, ref)
+				isKdk = true
+			}
+			if referenced[ref] {
+				isReferenced = true
+			}
+		}
+		// Dangling images keep only the digest of the repository they were pulled from
+		if len(kdkTags) == 0 && (len(image.RepoTags) == 0 || image.RepoTags[0] == "<none>:<none>") {
+			for _, ref := range image.RepoDigests {
+				if repository, _ := splitImageRef(ref); repositories[repository] {
+					isKdk = true
+				}
 			}
 		}
+		if isKdk && !isReferenced {
+			stale = append(stale, StaleImage{ID: image.ID, RepoTags: kdkTags, Size: image.Size})
+		}
 	}
+	return stale, nil
+}
 
-	// iterate through imageIds and add imageIds that are NOT associated with currently running containers
-	for imageId := range imageIds {
-		if utils.Contains(runningContainerImageIds, imageIds[imageId]) {
-		} else {
-			staleImageIds = append(staleImageIds, imageIds[imageId])
+// Remove a stale image: untag its KDK tags, or remove it by ID if untagged
+func (c *KdkEnvConfig) removeStaleImage(image StaleImage) error {
+	refs := image.RepoTags
+	if len(refs) == 0 {
+		refs = []string{image.ID}
+	}
+	for _, ref := range refs {
+		if _, err := c.DockerClient.ImageRemove(c.Ctx, ref, types.ImageRemoveOptions{PruneChildren: true}); err != nil {
+			return wrapDockerError(err)
 		}
 	}
+	return nil
+}
 
-	if len(staleImageIds) > 0 {
-		// iterate through staleImageIds, prmpt user to confirm deletion
-		for staleImage := range staleImageIds {
-			targetImage := staleImageIds[staleImage]
-			log.Infof("Delete stale KDK image [%s]?", targetImage)
-			prmpt := prompt.Prompt{
-				Text:     "Continue? [y/n] ",
-				Loop:     true,
-				Validate: prompt.ValidateYorN,
-			}
-			if result, err := prmpt.Run(); err != nil || result == "n" {
-				log.Error("KDK stale image deletion canceled or invalid input.")
-				return err
-			}
-			if _, err := cfg.DockerClient.ImageRemove(cfg.Ctx, targetImage, types.ImageRemoveOptions{Force: true, PruneChildren: true}); err != nil {
-				log.WithField("error", err).Fatalf("Failed to prune KDK image [%s]", targetImage)
-				return err
-			} else {
-				log.Infof("Deleted stale KDK image [%s]", targetImage)
-			}
-		}
-	} else {
+// Remove stale KDK images, confirming each.  With dryRun, only list them.
+func Prune(cfg KdkEnvConfig, dryRun bool) error {
+	log.Info("Starting Prune...")
+
+	staleImages, err := cfg.StaleImages()
+	if err != nil {
+		return err
+	}
+	if len(staleImages) == 0 {
 		log.Infof("No stale KDK images to delete")
+		return nil
+	}
+
+	for _, image := range staleImages {
+		if dryRun {
+			fmt.Printf("%s\t%s\n", image, units.HumanSize(float64(image.Size)))
+			continue
+		}
+		log.Infof("Delete stale KDK image [%s] (%s)?", image, units.HumanSize(float64(image.Size)))
+		prmpt := prompt.Prompt{
+			Text:     "Continue? [y/n] ",
+			Loop:     true,
+			Validate: prompt.ValidateYorN,
+		}
+		if result, err := prmpt.Run(); err != nil || result == "n" {
+			log.Error("KDK stale image deletion canceled or invalid input.")
+			return err
+		}
+		if err := cfg.removeStaleImage(image); err != nil {
+			return fmt.Errorf("failed to prune KDK image [%s]: %w", image, err)
+		}
+		log.Infof("Deleted stale KDK image [%s]", image)
 	}
 	return nil
 }
@@ -19,7 +19,9 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/jsonmessage"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"os"
+	"strings"
 )
 
 type ProgressDetail struct {
@@ -35,8 +37,15 @@ type ProgressMessage struct {
 }
 
 func Pull(cfg *KdkEnvConfig, force bool) error {
-	tag := cfg.ConfigFile.AppConfig.ImageTag
+	if err := cfg.validateImageConfig(); err != nil {
+		return err
+	}
+	tag := cfg.ImageTag()
 	if hasKdkImageWithTag(cfg, tag) {
+		if !hasImagePlatform(cfg, cfg.ImageCoordinates()) {
+			log.WithField("tag", tag).WithField("platform", cfg.ConfigFile.AppConfig.Platform).Info("Pulling KDK Image for configured platform")
+			return pullImage(cfg, cfg.ImageCoordinates())
+		}
 		if force {
 			log.WithField("tag", tag).Info("Re-pulling existing KDK Image")
 			return pullImage(cfg, cfg.ImageCoordinates())
@@ -50,13 +59,32 @@ func Pull(cfg *KdkEnvConfig, force bool) error {
 }
 
 func pullImage(cfg *KdkEnvConfig, imageCoordinates string) error {
+	cfg.warnDaemonProxy()
 
-	responseBody, err := cfg.DockerClient.ImagePull(cfg.Ctx, imageCoordinates, types.ImagePullOptions{})
-	if err != nil {
+	var responseBody io.ReadCloser
+	err := retryDocker("Pull KDK image", func() (err error) {
+		responseBody, err = cfg.DockerClient.ImagePull(cfg.Ctx, imageCoordinates, types.ImagePullOptions{Platform: cfg.ConfigFile.AppConfig.Platform})
 		return err
+	})
+	if err != nil {
+		return wrapImageError(err)
 	}
 	defer responseBody.Close()
 
 	outStream := command.NewOutStream(os.Stdout)
 	return jsonmessage.DisplayJSONMessagesToStream(responseBody, outStream, nil)
 }
+
+// check that the local image matches the configured platform (os/arch), if any
+func hasImagePlatform(cfg *KdkEnvConfig, imageCoordinates string) bool {
+	platform := cfg.ConfigFile.AppConfig.Platform
+	if platform == "" {
+		return true
+	}
+	image, _, err := cfg.DockerClient.ImageInspectWithRaw(cfg.Ctx, imageCoordinates)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(platform, "/")
+	return image.Os == parts[0] && image.Architecture == parts[1]
+}
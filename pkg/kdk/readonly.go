@@ -0,0 +1,77 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exits 1 if the target is writable.  A directory is probed by creating (and removing) a file in it,
+// a file by opening it for append without writing, so that a writable file is left unchanged.
+const readOnlyCheck = `t="$1"
+if [ -d "$t" ]; then
+	f="$t/.kdk-read-only-check-$$"
+	if touch "$f" 2>/dev/null; then rm -f "$f"; exit 1; fi
+elif ( : >> "$t" ) 2>/dev/null; then
+	exit 1
+fi`
+
+// Read-only mount targets of the KDK container
+func (c *KdkEnvConfig) readOnlyTargets() (targets []string) {
+	if c.ConfigFile.HostConfig == nil {
+		return nil
+	}
+	for _, m := range c.ConfigFile.HostConfig.Mounts {
+		if m.ReadOnly {
+			targets = append(targets, m.Target)
+		}
+	}
+	for _, bind := range c.ConfigFile.HostConfig.Binds {
+		if bindReadOnly(bind) {
+			targets = append(targets, bindTarget(bind))
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// Confirm that writes to each read-only mount of the started KDK container fail, since some
+// storage drivers let writes through.  Returns the targets which are writable.
+func (c *KdkEnvConfig) VerifyReadOnlyMounts() ([]string, error) {
+	var writable []string
+	for _, target := range c.readOnlyTargets() {
+		_, exitCode, err := c.containerExec("", "sh", "-c", readOnlyCheck, "sh", target)
+		if err != nil {
+			return writable, err
+		}
+		if exitCode != 0 {
+			writable = append(writable, target)
+		}
+	}
+	return writable, nil
+}
+
+// Warn about read-only mounts which are writable
+func (c *KdkEnvConfig) warnWritableMounts() {
+	writable, err := c.VerifyReadOnlyMounts()
+	if err != nil {
+		log.WithField("error", err).Warn("Failed to verify read-only mounts")
+	}
+	for _, target := range writable {
+		log.Warnf("Read-only mount [%s] is writable in the KDK container.  Host files under it are not protected", target)
+	}
+}
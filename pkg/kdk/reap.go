@@ -0,0 +1,77 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	log "github.com/sirupsen/logrus"
+)
+
+// Labels added to the KDK container at create time
+const (
+	userLabel    = "kdk.user"
+	createdLabel = "kdk.created" // RFC3339
+)
+
+// Find the KDK containers of any environment created more than olderThan ago (0 for any age),
+// and belonging to user ("" for any user)
+func (c *KdkEnvConfig) ReapableKdks(olderThan time.Duration, user string) ([]types.Container, error) {
+	args := filters.NewArgs(filters.Arg("label", "kdk"))
+	if user != "" {
+		args.Add("label", userLabel+"="+user)
+	}
+	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+
+	var reapable []types.Container
+	for _, container := range containers {
+		// containers created before the kdk.created label fall back to the docker creation time
+		created := time.Unix(container.Created, 0)
+		if label, ok := container.Labels[createdLabel]; ok {
+			if t, err := time.Parse(time.RFC3339, label); err == nil {
+				created = t
+			}
+		}
+		if time.Since(created) >= olderThan {
+			reapable = append(reapable, container)
+		}
+	}
+	return reapable, nil
+}
+
+// Stop and remove the KDK containers matched by ReapableKdks, e.g. from a shared build server
+// cleanup job.  Returns the names of the removed containers.
+func (c *KdkEnvConfig) ReapKdk(olderThan time.Duration, user string) ([]string, error) {
+	containers, err := c.ReapableKdks(olderThan, user)
+	if err != nil {
+		return nil, err
+	}
+	var reaped []string
+	for _, container := range containers {
+		name := strings.TrimPrefix(strings.Join(container.Names, ","), "/")
+		log.WithField("user", container.Labels[userLabel]).WithField("created", container.Labels[createdLabel]).Infof("Reaping KDK container [%s]", name)
+		if err := c.DockerClient.ContainerRemove(c.Ctx, container.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return reaped, wrapDockerError(err)
+		}
+		reaped = append(reaped, name)
+	}
+	return reaped, nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the docker named volumes declared in the KDK config
+func (c *KdkEnvConfig) namedVolumes() (names []string) {
+	if c.ConfigFile.HostConfig == nil {
+		return nil
+	}
+	for _, m := range c.ConfigFile.HostConfig.Mounts {
+		if m.Type == mount.TypeVolume && m.Source != "" {
+			names = append(names, m.Source)
+		}
+	}
+	return names
+}
+
+// Remove the KDK container and recreate it from config, e.g. after changing the image tag.
+// Named volumes declared in the config are kept when preserveVolumes is true, otherwise they
+// are removed along with the container.  The container filesystem and anonymous volumes are
+// always discarded.
+func (c *KdkEnvConfig) RecreateKdk(preserveVolumes bool) error {
+	container, err := c.findContainer()
+	if err != nil {
+		return err
+	}
+
+	volumes := c.namedVolumes()
+	if container != nil {
+		log.Infof("Removing KDK container [%s].  The container filesystem and anonymous volumes are discarded", c.ContainerName())
+		c.stopContainer(container.ID)
+		if err := c.DockerClient.ContainerRemove(c.Ctx, container.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			return wrapDockerError(err)
+		}
+		c.removeStatusFile()
+	}
+	for _, volume := range volumes {
+		if preserveVolumes {
+			log.Infof("Keeping named volume [%s]", volume)
+			continue
+		}
+		log.Infof("Removing named volume [%s]", volume)
+		if err := c.DockerClient.VolumeRemove(c.Ctx, volume, false); err != nil {
+			return wrapDockerError(err)
+		}
+	}
+
+	return c.Start()
+}
@@ -0,0 +1,295 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"archive/tar"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Sync mode for remote docker daemons, which can't see host directories.  Bind mounted host
+// directories are replaced by named volumes, which `kdk sync` keeps up to date with the host.
+type RemoteSyncConfig struct {
+	Include  []string `json:",omitempty"` // file patterns to sync (default all), matched against the base name or path relative to the mount
+	Exclude  []string `json:",omitempty"` // file patterns not to sync, e.g. .git or node_modules.  Excluded directories are skipped entirely
+	Interval string   `json:",omitempty"` // how often to scan for changes (default 2s)
+}
+
+const defaultRemoteSyncInterval = 2 * time.Second
+
+func validateRemoteSync(remoteSync *RemoteSyncConfig) error {
+	if remoteSync == nil {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, remoteSync.Include...), remoteSync.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid RemoteSync pattern [%s]: %v", pattern, err)
+		}
+	}
+	if remoteSync.Interval != "" {
+		if interval, err := time.ParseDuration(remoteSync.Interval); err != nil || interval <= 0 {
+			return fmt.Errorf("invalid RemoteSync Interval [%s]: must be a positive duration (e.g. 2s)", remoteSync.Interval)
+		}
+	}
+	return nil
+}
+
+func (r *RemoteSyncConfig) interval() time.Duration {
+	if interval, err := time.ParseDuration(r.Interval); err == nil && interval > 0 {
+		return interval
+	}
+	return defaultRemoteSyncInterval
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Named volume replacing the bind mount at target
+func (c *KdkEnvConfig) syncVolumeName(target string) string {
+	sum := sha1.Sum([]byte(target))
+	return fmt.Sprintf("%s-sync-%x", c.ContainerName(), sum[:4])
+}
+
+// A host directory synced to a volume mounted at Target in the KDK
+type syncedMount struct {
+	Source string
+	Target string
+}
+
+// The bind mounted host directories replaced by sync volumes.  Bind mounted files (e.g. the
+// public key) are left as is.
+func (c *KdkEnvConfig) syncedMounts() []syncedMount {
+	if c.ConfigFile.AppConfig.RemoteSync == nil || c.ConfigFile.HostConfig == nil {
+		return nil
+	}
+	var synced []syncedMount
+	for _, m := range c.ConfigFile.HostConfig.Mounts {
+		if m.Type != mount.TypeBind || m.ReadOnly {
+			continue
+		}
+		if info, err := os.Stat(m.Source); err != nil || !info.IsDir() {
+			continue
+		}
+		synced = append(synced, syncedMount{Source: m.Source, Target: m.Target})
+	}
+	return synced
+}
+
+// Replace the synced bind mounts of the effective config with their volumes
+func (c *KdkEnvConfig) applyRemoteSync(hostConfig *container.HostConfig) {
+	targets := map[string]bool{}
+	for _, s := range c.syncedMounts() {
+		targets[s.Target] = true
+	}
+	for i, m := range hostConfig.Mounts {
+		if m.Type == mount.TypeBind && targets[m.Target] {
+			hostConfig.Mounts[i] = mount.Mount{Type: mount.TypeVolume, Source: c.syncVolumeName(m.Target), Target: m.Target}
+		}
+	}
+}
+
+// Size, modification time, and mode of a synced file
+type syncState struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// Scan the syncable files and directories under source, by slash separated relative path
+func (c *KdkEnvConfig) scanSyncSource(source string) (map[string]syncState, error) {
+	remoteSync := c.ConfigFile.AppConfig.RemoteSync
+	files := map[string]syncState{}
+	err := filepath.Walk(source, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(remoteSync.Exclude, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		if !info.IsDir() && len(remoteSync.Include) > 0 && !matchAny(remoteSync.Include, rel) {
+			return nil
+		}
+		files[rel] = syncState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		return nil
+	})
+	return files, err
+}
+
+// Upload the files rels of source to target in the KDK container.  Ownership is set to the KDK user.
+func (c *KdkEnvConfig) uploadSyncFiles(source, target string, rels []string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		for _, rel := range rels {
+			file := filepath.Join(source, filepath.FromSlash(rel))
+			info, err := os.Stat(file)
+			if os.IsNotExist(err) {
+				// removed since the scan
+				continue
+			} else if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+			if err := addToTar(tw, file, rel, info); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(tw.Close())
+	}()
+	if err := c.DockerClient.CopyToContainer(c.Ctx, c.ContainerName(), target, reader,
+		types.CopyToContainerOptions{AllowOverwriteDirWithFile: true}); err != nil {
+		reader.Close()
+		return wrapDockerError(err)
+	}
+
+	return c.syncExec([]string{"chown", c.User() + ":"}, append([]string{""}, rels...), target)
+}
+
+// Remove the files rels from target in the KDK container
+func (c *KdkEnvConfig) removeSyncFiles(target string, rels []string) error {
+	return c.syncExec([]string{"rm", "-rf", "--"}, rels, target)
+}
+
+// Paths per exec, to stay well within the argument length limit
+const syncExecBatch = 500
+
+// Run command on the paths rels under target in the KDK container, as root, in batches
+func (c *KdkEnvConfig) syncExec(command, rels []string, target string) error {
+	for start := 0; start < len(rels); start += syncExecBatch {
+		end := start + syncExecBatch
+		if end > len(rels) {
+			end = len(rels)
+		}
+		args := append([]string{}, command...)
+		for _, rel := range rels[start:end] {
+			args = append(args, path.Join(target, rel))
+		}
+		out, exitCode, err := c.containerExec("root", args...)
+		if err != nil {
+			return err
+		}
+		if exitCode != 0 {
+			return fmt.Errorf("%s failed (exit %d): %s", command[0], exitCode, strings.TrimSpace(out))
+		}
+	}
+	return nil
+}
+
+// Changed (including new) and removed paths between scans.  A directory changes only with its mode,
+// and a file with its size, modification time, or mode (e.g. chmod +x).
+func diffSyncStates(before, after map[string]syncState) (changed, removed []string) {
+	for rel, state := range after {
+		if old, ok := before[rel]; !ok || old.mode != state.mode || (!state.mode.IsDir() && (old.size != state.size || !old.modTime.Equal(state.modTime))) {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range before {
+		if _, ok := after[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// Sync the RemoteSync host directories into their volumes in the running KDK container.  The
+// first pass copies all files and removes none.  With watch, changes are then synced every
+// Interval until an error occurs.
+func (c *KdkEnvConfig) SyncMounts(watch bool) error {
+	synced := c.syncedMounts()
+	if len(synced) == 0 {
+		return fmt.Errorf("no host directories to sync: set AppConfig.RemoteSync and mount host directories")
+	}
+	if running, err := c.IsRunning(); err != nil {
+		return err
+	} else if !running {
+		return fmt.Errorf("KDK container [%s] is not running", c.ContainerName())
+	}
+
+	states := make([]map[string]syncState, len(synced))
+	for i, s := range synced {
+		files, err := c.scanSyncSource(s.Source)
+		if err != nil {
+			return err
+		}
+		changed, _ := diffSyncStates(nil, files)
+		if err := c.uploadSyncFiles(s.Source, s.Target, changed); err != nil {
+			return fmt.Errorf("failed to sync %s: %v", s.Source, err)
+		}
+		log.Infof("Synced %d files of %s to %s", len(changed), s.Source, s.Target)
+		states[i] = files
+	}
+
+	for watch {
+		time.Sleep(c.ConfigFile.AppConfig.RemoteSync.interval())
+		for i, s := range synced {
+			files, err := c.scanSyncSource(s.Source)
+			if err != nil {
+				return err
+			}
+			changed, removed := diffSyncStates(states[i], files)
+			if len(removed) > 0 {
+				if err := c.removeSyncFiles(s.Target, removed); err != nil {
+					return fmt.Errorf("failed to sync %s: %v", s.Source, err)
+				}
+			}
+			if len(changed) > 0 {
+				if err := c.uploadSyncFiles(s.Source, s.Target, changed); err != nil {
+					return fmt.Errorf("failed to sync %s: %v", s.Source, err)
+				}
+			}
+			if len(changed)+len(removed) > 0 {
+				log.Infof("Synced %s: %d changed, %d removed", s.Source, len(changed), len(removed))
+			}
+			states[i] = files
+		}
+	}
+	return nil
+}
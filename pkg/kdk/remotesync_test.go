@@ -0,0 +1,132 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchAny(t *testing.T) {
+
+	tests := []struct {
+		patterns []string
+		rel      string
+		match    bool
+	}{
+		{nil, "main.go", false},
+		{[]string{"*.go"}, "main.go", true},
+		{[]string{"*.go"}, "pkg/kdk/main.go", true}, // base name
+		{[]string{"*.go"}, "main.py", false},
+		{[]string{".git"}, "src/.git", true},
+		{[]string{"pkg/*"}, "pkg/kdk", true}, // relative path
+		{[]string{"pkg/*"}, "pkg/kdk/main.go", false},
+		{[]string{"*.py", "node_modules"}, "web/node_modules", true},
+	}
+	for _, test := range tests {
+		if match := matchAny(test.patterns, test.rel); match != test.match {
+			t.Logf("matchAny(%q, [%s]) is %v, expected %v", test.patterns, test.rel, match, test.match)
+			t.FailNow()
+		}
+	}
+}
+
+func TestDiffSyncStates(t *testing.T) {
+
+	now := time.Now()
+	file := syncState{size: 10, modTime: now, mode: 0644}
+	dir := syncState{modTime: now, mode: os.ModeDir | 0755}
+
+	tests := []struct {
+		name            string
+		before, after   map[string]syncState
+		changed, remove []string
+	}{
+		{"unchanged", map[string]syncState{"a": file, "d": dir}, map[string]syncState{"a": file, "d": dir}, nil, nil},
+		{"new", map[string]syncState{}, map[string]syncState{"a": file, "d": dir}, []string{"a", "d"}, nil},
+		{"removed", map[string]syncState{"a": file, "d": dir}, map[string]syncState{}, nil, []string{"a", "d"}},
+		{"size", map[string]syncState{"a": file}, map[string]syncState{"a": {size: 11, modTime: now, mode: 0644}}, []string{"a"}, nil},
+		{"mtime", map[string]syncState{"a": file}, map[string]syncState{"a": {size: 10, modTime: now.Add(time.Second), mode: 0644}}, []string{"a"}, nil},
+		{"file mode", map[string]syncState{"a": file}, map[string]syncState{"a": {size: 10, modTime: now, mode: 0755}}, []string{"a"}, nil},
+		{"dir mtime", map[string]syncState{"d": dir}, map[string]syncState{"d": {modTime: now.Add(time.Second), mode: os.ModeDir | 0755}}, nil, nil},
+		{"dir mode", map[string]syncState{"d": dir}, map[string]syncState{"d": {modTime: now, mode: os.ModeDir | 0700}}, []string{"d"}, nil},
+	}
+	for _, test := range tests {
+		changed, removed := diffSyncStates(test.before, test.after)
+		if !reflect.DeepEqual(changed, test.changed) || !reflect.DeepEqual(removed, test.remove) {
+			t.Logf("diffSyncStates %s: changed %q removed %q, expected changed %q removed %q",
+				test.name, changed, removed, test.changed, test.remove)
+			t.FailNow()
+		}
+	}
+}
+
+func TestScanSyncSource(t *testing.T) {
+
+	source, err := ioutil.TempDir("", "kdk-sync")
+	if err != nil {
+		t.Log("Failed to create temp dir.", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(source)
+	for _, rel := range []string{"main.go", "README.md", "pkg/kdk/kdk.go", ".git/HEAD", "web/node_modules/lib.go"} {
+		file := filepath.Join(source, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Log("Failed to create dir.", err)
+			t.FailNow()
+		}
+		if err := ioutil.WriteFile(file, []byte(rel), 0644); err != nil {
+			t.Log("Failed to write file.", err)
+			t.FailNow()
+		}
+	}
+
+	tests := []struct {
+		include, exclude []string
+		expected         []string
+	}{
+		{nil, nil, []string{".git", ".git/HEAD", "README.md", "main.go", "pkg", "pkg/kdk", "pkg/kdk/kdk.go",
+			"web", "web/node_modules", "web/node_modules/lib.go"}},
+		// excluded directories are skipped entirely
+		{nil, []string{".git", "node_modules"}, []string{"README.md", "main.go", "pkg", "pkg/kdk", "pkg/kdk/kdk.go", "web"}},
+		// includes select files only, so directories are kept
+		{[]string{"*.go"}, []string{"node_modules"}, []string{".git", "main.go", "pkg", "pkg/kdk", "pkg/kdk/kdk.go", "web"}},
+	}
+	for _, test := range tests {
+		env := &KdkEnvConfig{}
+		env.ConfigFile.AppConfig.RemoteSync = &RemoteSyncConfig{Include: test.include, Exclude: test.exclude}
+		files, err := env.scanSyncSource(source)
+		if err != nil {
+			t.Log("scanSyncSource failed.", err)
+			t.FailNow()
+		}
+		var rels []string
+		for rel := range files {
+			rels = append(rels, rel)
+		}
+		sort.Strings(rels)
+		if !reflect.DeepEqual(rels, test.expected) {
+			t.Logf("scanSyncSource include %q exclude %q found [%s], expected [%s]", test.include, test.exclude,
+				strings.Join(rels, " "), strings.Join(test.expected, " "))
+			t.FailNow()
+		}
+	}
+}
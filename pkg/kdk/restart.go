@@ -26,7 +26,7 @@ func Restart(cfg KdkEnvConfig) {
 	// Create snapshot of running KDK container
 	snapshotName, err := Snapshot(cfg)
 	if err != nil {
-		log.WithField("err", err).Fatal("Failed to create KDK image snapshot")
+		log.WithField("error", err).Fatal("Failed to create KDK image snapshot")
 	}
 
 	// Destroy running KDK container
@@ -37,6 +37,8 @@ func Restart(cfg KdkEnvConfig) {
 	cfg.ConfigFile.ContainerConfig.Image = snapshotName
 
 	// Start KDK container with snapshot image
-	cfg.Start()
+	if err := cfg.Start(); err != nil {
+		log.WithField("error", err).Fatal("Failed to start KDK container")
+	}
 	log.Info("KDK container restarted")
 }
@@ -0,0 +1,63 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// Maximum number of retries of a docker API call failing with a transient error
+	DockerMaxRetries = 3
+	// Delay before the first retry, doubled on each subsequent retry
+	dockerRetryDelay = 500 * time.Millisecond
+)
+
+// Call fn, retrying with exponential backoff while it fails with a transient docker error
+func retryDocker(description string, fn func() error) error {
+	delay := dockerRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > DockerMaxRetries || !isTransientDockerError(err) {
+			return err
+		}
+		log.WithField("error", err).Warnf("%s failed [attempt %d/%d].  Retrying in %v", description, attempt, DockerMaxRetries+1, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Transient errors are daemon availability and network failures.  Requests the daemon
+// rejected (not found, conflict, port in use) fail the same way on retry.
+func isTransientDockerError(err error) bool {
+	if errors.Is(wrapDockerError(err), ErrPortInUse) {
+		return false
+	}
+	if errdefs.IsNotFound(err) || errdefs.IsConflict(err) || errdefs.IsInvalidParameter(err) ||
+		errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return false
+	}
+	if client.IsErrConnectionFailed(err) || errdefs.IsSystem(err) || errdefs.IsUnavailable(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
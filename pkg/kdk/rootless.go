@@ -0,0 +1,107 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// useradd within the KDK bootstrap assigns the first regular uid to the KDK user
+const kdkUserUID = 1000
+
+// A contiguous range of subordinate ids, as listed in /etc/subuid or /etc/subgid
+type subIDRange struct {
+	Start int
+	Count int
+}
+
+// Checks whether the docker daemon is running in rootless mode
+func (c *KdkEnvConfig) IsRootless() bool {
+	return c.hasSecurityOption("rootless")
+}
+
+// Parse the subordinate id ranges assigned to user from /etc/subuid or /etc/subgid formatted input
+func parseSubIDs(r io.Reader, user string) ([]subIDRange, error) {
+	var ranges []subIDRange
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) != 3 || fields[0] != user {
+			continue
+		}
+		start, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid subordinate id start %q", fields[1])
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid subordinate id count %q", fields[2])
+		}
+		ranges = append(ranges, subIDRange{Start: start, Count: count})
+	}
+	return ranges, scanner.Err()
+}
+
+// Translate an id inside a rootless container to the id that owns the file on the host.
+// In rootless mode container root is the host user and container ids 1..N map onto the
+// subordinate id ranges in order.
+func rootlessHostID(containerID int, hostID int, ranges []subIDRange) (int, error) {
+	if containerID == 0 {
+		return hostID, nil
+	}
+	offset := containerID - 1
+	for _, r := range ranges {
+		if offset < r.Count {
+			return r.Start + offset, nil
+		}
+		offset -= r.Count
+	}
+	return 0, fmt.Errorf("id %d is not mapped by the subordinate id ranges", containerID)
+}
+
+// Warn about file ownership under rootless docker, reporting where the KDK user's files land on the host
+func (c *KdkEnvConfig) warnRootless(containerUID int) {
+	log.Warn("Docker is running in rootless mode.")
+	log.Warn("Mounted host files will appear owned by root inside the KDK, and files created by the KDK user will be owned by a subordinate uid on the host.")
+
+	f, err := os.Open("/etc/subuid")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	ranges, err := parseSubIDs(f, c.User())
+	if err != nil {
+		return
+	}
+	if hostUID, err := rootlessHostID(containerUID, os.Getuid(), ranges); err == nil {
+		log.Warnf("KDK container uid %d maps to host uid %d", containerUID, hostUID)
+	}
+}
+
+// Whether the docker daemon remaps container user namespaces (dockerd --userns-remap)
+func (c *KdkEnvConfig) IsUsernsRemap() bool {
+	return c.hasSecurityOption("userns")
+}
@@ -0,0 +1,55 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRootlessHostID(t *testing.T) {
+
+	subuid := strings.Join([]string{
+		"# subordinate uids",
+		"bob:200000:65536",
+		"alice:100000:65536",
+	}, "\n")
+
+	ranges, err := parseSubIDs(strings.NewReader(subuid), "alice")
+	if err != nil || len(ranges) != 1 {
+		t.Logf("parseSubIDs returned %v, %v", ranges, err)
+		t.FailNow()
+	}
+
+	// container root is the host user
+	hostID, err := rootlessHostID(0, 1000, ranges)
+	if err != nil || hostID != 1000 {
+		t.Logf("Container root mapped to %d, %v", hostID, err)
+		t.FailNow()
+	}
+
+	// the KDK user is shifted into the subordinate range
+	hostID, err = rootlessHostID(kdkUserUID, 1000, ranges)
+	if err != nil || hostID != 100999 {
+		t.Logf("KDK user mapped to %d, %v", hostID, err)
+		t.FailNow()
+	}
+
+	// ids beyond the subordinate range are unmapped
+	if _, err := rootlessHostID(70000, 1000, ranges); err == nil {
+		t.Log("rootlessHostID mapped an id outside of the subordinate range.")
+		t.FailNow()
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Shells installed in the KDK image
+var schemaShells = []string{"/bin/bash", "/usr/local/bin/zsh", "/bin/sh"}
+
+// Required config.yaml fields, by definition name.  ImageTag may be empty with ImageTags, which
+// validateImageConfig checks.
+var schemaRequired = map[string][]string{
+	"kdk.configFile": {"AppConfig", "ContainerConfig", "HostConfig"},
+	"kdk.AppConfig":  {"Name", "Port", "ImageRepository"},
+}
+
+// Collects the JSON schema definitions of struct types, which may be recursive
+type schemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+// Returns a JSON Schema (draft-07) of config.yaml, e.g. for editor autocomplete and validation.
+// ghodss/yaml maps YAML through the json struct tags, so the schema follows them.
+func GenerateSchema() ([]byte, error) {
+	g := &schemaGenerator{definitions: map[string]interface{}{}}
+	root := g.schema(reflect.TypeOf(configFile{}))
+	schema := map[string]interface{}{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"title":       "KDK config.yaml",
+		"$ref":        root["$ref"],
+		"definitions": g.definitions,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // base64
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			properties := map[string]interface{}{}
+			g.properties(t, properties)
+			return map[string]interface{}{"type": "object", "properties": properties}
+		}
+		name := t.String()
+		if _, ok := g.definitions[name]; !ok {
+			g.definitions[name] = nil // placeholder, for recursive types
+			properties := map[string]interface{}{}
+			g.properties(t, properties)
+			definition := map[string]interface{}{"type": "object", "properties": properties}
+			if required, ok := schemaRequired[name]; ok {
+				definition["required"] = required
+			}
+			g.definitions[name] = definition
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// Add the json properties of struct t, flattening embedded structs as encoding/json does
+func (g *schemaGenerator) properties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			g.properties(field.Type, properties)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name := field.Name
+		if tag != "" {
+			name = tag
+		}
+		properties[name] = g.fieldSchema(t, field)
+	}
+}
+
+// Field schema, with enums for the AppConfig fields that take a fixed set of values.  LogDriver
+// also takes logging plugins, so the built-in drivers are only examples.
+func (g *schemaGenerator) fieldSchema(t reflect.Type, field reflect.StructField) map[string]interface{} {
+	schema := g.schema(field.Type)
+	if t != reflect.TypeOf(AppConfig{}) {
+		return schema
+	}
+	switch field.Name {
+	case "Shell":
+		schema["enum"] = schemaShells
+	case "LogDriver":
+		schema["examples"] = logDrivers[1:]
+	case "Relabel":
+		schema["enum"] = []string{"", "shared", "private"}
+	}
+	return schema
+}
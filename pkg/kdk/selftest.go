@@ -0,0 +1,131 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cisco-sso/kdk/pkg/utils"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	log "github.com/sirupsen/logrus"
+)
+
+// Builds a disposable, auto-removed KDK environment from the image settings of this environment
+func (c *KdkEnvConfig) selfTestEnv() *KdkEnvConfig {
+	port := strconv.Itoa(utils.GetPort())
+	env := &KdkEnvConfig{DockerClient: c.DockerClient, Ctx: c.Ctx, HomeDir: c.HomeDir}
+	env.ConfigFile.AppConfig = AppConfig{
+		Name:            "kdk-selftest-" + port,
+		Port:            port,
+		HostIP:          "127.0.0.1",
+		ImageRepository: c.ConfigFile.AppConfig.ImageRepository,
+		ImageTag:        c.ImageTag(),
+		Platform:        c.ConfigFile.AppConfig.Platform,
+		DotfilesRepo:    c.ConfigFile.AppConfig.DotfilesRepo,
+		Shell:           "/bin/bash",
+		AutoRemove:      true,
+		KeyProvider:     c.ConfigFile.AppConfig.KeyProvider,
+	}
+	if env.ConfigFile.AppConfig.ImageRepository == "" {
+		env.ConfigFile.AppConfig.ImageRepository = "ciscosso/kdk"
+	}
+	if env.ConfigFile.AppConfig.ImageTag == "" {
+		env.ConfigFile.AppConfig.ImageTag = Version
+	}
+	if env.ConfigFile.AppConfig.DotfilesRepo == "" {
+		env.ConfigFile.AppConfig.DotfilesRepo = "https://github.com/cisco-sso/yadm-dotfiles.git"
+	}
+
+	env.ConfigFile.ContainerConfig = &container.Config{
+		Hostname: env.hostname(),
+		Image:    env.ImageCoordinates(),
+		Tty:      true,
+		Env: []string{
+			"KDK_USERNAME=" + env.User(),
+			"KDK_SHELL=" + env.ConfigFile.AppConfig.Shell,
+			"KDK_DOTFILES_REPO=" + env.ConfigFile.AppConfig.DotfilesRepo,
+		},
+		ExposedPorts: nat.PortSet{"2022/tcp": struct{}{}},
+		Volumes:      map[string]struct{}{"/tmp/id_rsa.pub": {}},
+		Labels:       map[string]string{"kdk": Version, "kdk.selftest": "true"},
+	}
+	env.ConfigFile.HostConfig = &container.HostConfig{
+		Privileged: true,
+		PortBindings: nat.PortMap{
+			"2022/tcp": []nat.PortBinding{{HostIP: "127.0.0.1", HostPort: port}},
+		},
+		Mounts: []mount.Mount{
+			{Type: mount.TypeBind, Source: env.PublicKeyPath(), Target: "/tmp/id_rsa.pub", ReadOnly: true},
+		},
+		AutoRemove: true,
+	}
+	return env
+}
+
+// Verify a KDK install end to end: create a throwaway environment, confirm ssh connectivity
+// with the KDK keypair by running `echo ok`, then tear everything down.  Returns nil on pass.
+func (c *KdkEnvConfig) SelfTest(logger log.FieldLogger) error {
+	// The keypair is shared with the real environments, e.g. an ssh-agent public key without a
+	//   private key, so only create one through the KeyProvider when none exists
+	if _, err := os.Stat(c.PublicKeyPath()); os.IsNotExist(err) {
+		if err := c.EnsureKey(); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	env := c.selfTestEnv()
+	logger = logger.WithField("name", env.ConfigFile.AppConfig.Name)
+
+	if err := os.MkdirAll(env.ConfigDir(), 0700); err != nil {
+		return err
+	}
+	defer func() {
+		logger.Info("Tearing down self-test KDK environment")
+		if container, findErr := env.findContainer(); findErr == nil && container != nil {
+			if rmErr := env.DockerClient.ContainerRemove(env.Ctx, container.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); rmErr != nil {
+				logger.WithField("error", rmErr).Warn("Failed to remove self-test KDK container")
+			}
+		}
+		if rmErr := os.RemoveAll(env.ConfigDir()); rmErr != nil {
+			logger.WithField("error", rmErr).Warn("Failed to remove self-test KDK config")
+		}
+	}()
+	if err := env.SaveKdkConfig(); err != nil {
+		return err
+	}
+
+	logger.Info("Starting self-test KDK environment")
+	if err := env.Start(); err != nil {
+		return fmt.Errorf("self-test failed to start KDK: %w", err)
+	}
+
+	logger.Info("Running `echo ok` via ssh")
+	out, err := env.Output("echo ok")
+	if err != nil {
+		return fmt.Errorf("self-test failed to ssh to KDK: %w", err)
+	}
+	if strings.TrimSpace(out) != "ok" {
+		return fmt.Errorf("self-test got unexpected ssh output [%s]", strings.TrimSpace(out))
+	}
+	logger.Info("ssh connectivity ok")
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// SELinux relabel modes, as the bind mount :z (shared between containers) and :Z (private) options
+var relabelOptions = map[string]string{"shared": "z", "private": "Z"}
+
+// A relabel mode must be "", "shared", or "private"
+func validateRelabel(relabel string) error {
+	if _, ok := relabelOptions[relabel]; relabel != "" && !ok {
+		return fmt.Errorf("invalid Relabel [%s]: must be shared or private", relabel)
+	}
+	return nil
+}
+
+// Whether the docker daemon enforces SELinux labels on containers
+func (c *KdkEnvConfig) IsSELinux() bool {
+	return c.hasSecurityOption("selinux")
+}
+
+// The docker mount API has no relabel option, so a relabeled bind mount is expressed as a
+// HostConfig.Binds entry, e.g. /src:/dst:ro,z.  ok is false when no relabel applies, either
+// because none was requested or because the daemon does not enforce SELinux.
+func (c *KdkEnvConfig) relabeledBind(m mount.Mount, relabel string) (bind string, ok bool) {
+	if relabel == "" || m.Type != mount.TypeBind {
+		return "", false
+	}
+	if !c.IsSELinux() {
+		log.Debugf("Docker daemon does not enforce SELinux.  Not relabeling mount [%s]", m.Target)
+		return "", false
+	}
+	mode := "rw"
+	if m.ReadOnly {
+		mode = "ro"
+	}
+	return fmt.Sprintf("%s:%s:%s,%s", m.Source, m.Target, mode, relabelOptions[relabel]), true
+}
+
+// Container target of a HostConfig.Binds entry.  Sources may contain ':' on Windows (C:\...),
+// so the target is found from the right.
+func bindTarget(bind string) string {
+	parts := strings.Split(bind, ":")
+	if len(parts) >= 3 {
+		return parts[len(parts)-2]
+	}
+	return parts[len(parts)-1]
+}
+
+// Whether a HostConfig.Binds entry is mounted read-only (the "ro" option)
+func bindReadOnly(bind string) bool {
+	parts := strings.Split(bind, ":")
+	if len(parts) < 3 {
+		return false
+	}
+	for _, option := range strings.Split(parts[len(parts)-1], ",") {
+		if option == "ro" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Login shell used when the configured Shell is not installed in the image
+const fallbackShell = "/bin/sh"
+
+// Whether shell is installed in the started KDK container
+func (c *KdkEnvConfig) hasShell(shell string) (bool, error) {
+	_, exitCode, err := c.containerExec("", "sh", "-c", `command -v "$1"`, "sh", shell)
+	if err != nil {
+		return false, err
+	}
+	return exitCode == 0, nil
+}
+
+// The login shell for the KDK user: the configured Shell, or /bin/sh with a warning if the image
+// lacks it.  Otherwise the bootstrap fails, and ssh reports only that the connection was closed.
+func (c *KdkEnvConfig) loginShell() (string, error) {
+	shell := c.ConfigFile.AppConfig.Shell
+	if shell == "" {
+		// the bootstrap defaults to the image's SHELL
+		return "", nil
+	}
+	ok, err := c.hasShell(shell)
+	if err != nil || ok {
+		return shell, err
+	}
+	log.Warnf("KDK shell [%s] is not installed in image [%s].  Falling back to %s.  Install it in the image, or run `kdk init --shell` with an installed shell",
+		shell, c.ImageCoordinates(), fallbackShell)
+	if ok, err := c.hasShell(fallbackShell); err != nil {
+		return "", err
+	} else if !ok {
+		return "", fmt.Errorf("neither KDK shell [%s] nor %s is installed in image [%s]", shell, fallbackShell, c.ImageCoordinates())
+	}
+	return fallbackShell, nil
+}
@@ -0,0 +1,205 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	log "github.com/sirupsen/logrus"
+)
+
+// A companion service container (e.g. a database) started alongside the KDK on its network
+type SidecarSpec struct {
+	Name  string   // network alias, and container name suffix
+	Image string   // image coordinates
+	Ports []string `json:",omitempty"` // published ports, as `docker run -p`
+	Env   []string `json:",omitempty"` // KEY=value
+}
+
+// Time to wait for a sidecar to become ready
+const sidecarTimeout = 60 * time.Second
+
+// Label identifying sidecar containers, valued with the KDK container name
+const sidecarLabel = "kdk.sidecar"
+
+// Sidecar container name
+func (c *KdkEnvConfig) sidecarContainerName(sidecar SidecarSpec) string {
+	return c.ContainerName() + "-" + sidecar.Name
+}
+
+// Sidecars require a user-defined network, and names usable as network aliases
+func validateSidecars(network string, sidecars []SidecarSpec) error {
+	if len(sidecars) == 0 {
+		return nil
+	}
+	if !isUserDefinedNetwork(network) {
+		return fmt.Errorf("invalid Sidecars: a user-defined Network is required, got [%s]", network)
+	}
+	names := map[string]bool{}
+	for _, sidecar := range sidecars {
+		if !dnsLabel.MatchString(sidecar.Name) || names[sidecar.Name] {
+			return fmt.Errorf("invalid Sidecars: name [%s] must be a unique DNS label", sidecar.Name)
+		}
+		names[sidecar.Name] = true
+		if sidecar.Image == "" {
+			return fmt.Errorf("invalid Sidecars: [%s] requires an Image", sidecar.Name)
+		}
+		if _, _, err := nat.ParsePortSpecs(sidecar.Ports); err != nil {
+			return fmt.Errorf("invalid Sidecars: [%s] Ports: %v", sidecar.Name, err)
+		}
+	}
+	return nil
+}
+
+// Start the configured sidecars on the KDK network, and wait for them to become ready
+func (c *KdkEnvConfig) StartSidecars() error {
+	if len(c.ConfigFile.AppConfig.Sidecars) == 0 {
+		return nil
+	}
+	if err := c.ensureNetwork(); err != nil {
+		return err
+	}
+	for _, sidecar := range c.ConfigFile.AppConfig.Sidecars {
+		if err := c.startSidecar(sidecar); err != nil {
+			return fmt.Errorf("failed to start sidecar [%s]: %w", sidecar.Name, err)
+		}
+	}
+	for _, sidecar := range c.ConfigFile.AppConfig.Sidecars {
+		if err := c.waitForSidecar(sidecar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create the KDK user-defined network if it doesn't exist
+func (c *KdkEnvConfig) ensureNetwork() error {
+	name := c.ConfigFile.AppConfig.Network
+	if _, err := c.DockerClient.NetworkInspect(c.Ctx, name, types.NetworkInspectOptions{}); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return wrapDockerError(err)
+	}
+	log.Infof("Creating docker network [%s]", name)
+	_, err := c.DockerClient.NetworkCreate(c.Ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Labels:         map[string]string{"kdk": Version},
+	})
+	return wrapDockerError(err)
+}
+
+func (c *KdkEnvConfig) startSidecar(sidecar SidecarSpec) error {
+	name := c.sidecarContainerName(sidecar)
+	if inspect, err := c.DockerClient.ContainerInspect(c.Ctx, name); err == nil {
+		if inspect.State.Running {
+			return nil
+		}
+		log.Infof("Starting sidecar [%s]", name)
+		return wrapDockerError(c.DockerClient.ContainerStart(c.Ctx, inspect.ID, types.ContainerStartOptions{}))
+	} else if !client.IsErrNotFound(err) {
+		return wrapDockerError(err)
+	}
+
+	if _, _, err := c.DockerClient.ImageInspectWithRaw(c.Ctx, sidecar.Image); client.IsErrNotFound(err) {
+		log.Infof("Pulling sidecar image [%s]", sidecar.Image)
+		body, err := c.DockerClient.ImagePull(c.Ctx, sidecar.Image, types.ImagePullOptions{})
+		if err != nil {
+			return wrapImageError(err)
+		}
+		_, err = io.Copy(ioutil.Discard, body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	exposedPorts, portBindings, err := nat.ParsePortSpecs(sidecar.Ports)
+	if err != nil {
+		return err
+	}
+	log.Infof("Creating sidecar [%s] from image [%s]", name, sidecar.Image)
+	resp, err := c.DockerClient.ContainerCreate(c.Ctx,
+		&container.Config{
+			Image:        sidecar.Image,
+			Env:          sidecar.Env,
+			ExposedPorts: exposedPorts,
+			Labels:       map[string]string{sidecarLabel: c.ContainerName()},
+		},
+		&container.HostConfig{
+			PortBindings: portBindings,
+			NetworkMode:  container.NetworkMode(c.ConfigFile.AppConfig.Network),
+		},
+		&network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				c.ConfigFile.AppConfig.Network: {Aliases: []string{sidecar.Name}},
+			},
+		},
+		name,
+	)
+	if err != nil {
+		return wrapImageError(err)
+	}
+	return wrapDockerError(c.DockerClient.ContainerStart(c.Ctx, resp.ID, types.ContainerStartOptions{}))
+}
+
+// Wait for a sidecar to be healthy, if its image defines a healthcheck, otherwise running
+func (c *KdkEnvConfig) waitForSidecar(sidecar SidecarSpec) error {
+	name := c.sidecarContainerName(sidecar)
+	deadline := time.Now().Add(sidecarTimeout)
+	for {
+		inspect, err := c.DockerClient.ContainerInspect(c.Ctx, name)
+		if err != nil {
+			return wrapDockerError(err)
+		}
+		switch {
+		case !inspect.State.Running && inspect.State.Status == "exited":
+			return fmt.Errorf("sidecar [%s] exited with code %d", name, inspect.State.ExitCode)
+		case inspect.State.Health == nil && inspect.State.Running:
+			return nil
+		case inspect.State.Health != nil && inspect.State.Health.Status == types.Healthy:
+			return nil
+		case time.Now().After(deadline):
+			return fmt.Errorf("sidecar [%s] was not ready within %v", name, sidecarTimeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// Remove the sidecars of the KDK container
+func (c *KdkEnvConfig) RemoveSidecars() error {
+	containers, err := c.DockerClient.ContainerList(c.Ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", sidecarLabel+"="+c.ContainerName())),
+	})
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	for _, sidecar := range containers {
+		log.Infof("Removing sidecar %v", sidecar.Names)
+		if err := c.DockerClient.ContainerRemove(c.Ctx, sidecar.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			return wrapDockerError(err)
+		}
+	}
+	return nil
+}
@@ -23,7 +23,7 @@ import (
 
 func Snapshot(cfg KdkEnvConfig) (string, error) {
 	snapshotName := "ciscosso/kdk" + ":" + cfg.User() + "-" + cfg.ConfigFile.AppConfig.Name + "-" + time.Now().Format("20060102150405")
-	_, err := cfg.DockerClient.ContainerCommit(cfg.Ctx, cfg.ConfigFile.AppConfig.Name, types.ContainerCommitOptions{Reference: snapshotName})
+	_, err := cfg.DockerClient.ContainerCommit(cfg.Ctx, cfg.ContainerName(), types.ContainerCommitOptions{Reference: snapshotName})
 	if err != nil {
 		log.WithField("error", err).Fatal("Failed to create snapshot of KDK container")
 		return "", err
@@ -0,0 +1,182 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	log "github.com/sirupsen/logrus"
+)
+
+// Relay stdio to the container sshd, with whichever tool the image has
+const sshdRelay = `if command -v socat >/dev/null 2>&1; then exec socat - TCP:127.0.0.1:2022; fi
+if command -v nc >/dev/null 2>&1; then exec nc 127.0.0.1 2022; fi
+exec bash -c 'exec 3<>/dev/tcp/127.0.0.1/2022; cat <&3 & exec cat >&3'`
+
+// Filename of the ssh socket within the KDK config dir
+const sshSocket = "ssh.sock"
+
+func (c *KdkEnvConfig) SshSocketPath() string {
+	return filepath.Join(c.ConfigDir(), sshSocket)
+}
+
+// Connect to the container sshd through a docker exec relay, rather than a published port
+func (c *KdkEnvConfig) dialSshd() (net.Conn, error) {
+	exec, err := c.DockerClient.ContainerExecCreate(c.Ctx, c.ContainerName(), types.ExecConfig{
+		Tty:          false,
+		Cmd:          []string{"sh", "-c", sshdRelay},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+	resp, err := c.DockerClient.ContainerExecAttach(c.Ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, wrapDockerError(err)
+	}
+
+	// Without a tty the exec output is multiplexed, so demultiplex stdout into the pipe
+	local, remote := net.Pipe()
+	go func() {
+		stdcopy.StdCopy(remote, ioutil.Discard, resp.Reader)
+		remote.Close()
+		resp.Close()
+	}()
+	go func() {
+		io.Copy(resp.Conn, remote)
+		resp.CloseWrite()
+	}()
+	return local, nil
+}
+
+// Relay in and out to the container sshd, e.g. as an ssh ProxyCommand (`kdk ssh-proxy`)
+func (c *KdkEnvConfig) SshProxy(in io.Reader, out io.Writer) error {
+	conn, err := c.dialSshd()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	go io.Copy(conn, in)
+	_, err = io.Copy(out, conn)
+	return err
+}
+
+// Serve a Unix socket, readable only by the user, relaying each connection to the container sshd
+func (c *KdkEnvConfig) ServeSshSocket() error {
+	socketPath := c.SshSocketPath()
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		return err
+	}
+	log.Infof("Serving KDK ssh on %s", socketPath)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := c.SshProxy(conn, conn); err != nil {
+				log.WithField("error", err).Warn("KDK ssh socket connection failed")
+			}
+		}()
+	}
+}
+
+// Environment selecting the docker daemon when DOCKER_HOST is used instead of a docker context
+var dockerHostEnv = []string{"DOCKER_HOST", "DOCKER_TLS_VERIFY", "DOCKER_CERT_PATH", "DOCKER_API_VERSION"}
+
+// ssh ProxyCommand running this kdk binary against the same docker daemon, which ssh runs through
+// a shell, e.g. from ~/.ssh/config where DOCKER_HOST or DOCKER_CONTEXT may not be set
+func (c *KdkEnvConfig) sshProxyCommand() string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "kdk"
+	}
+	args := []string{proxyCommandQuote(exe), "ssh-proxy", "--name", proxyCommandQuote(c.ConfigFile.AppConfig.Name)}
+	if dockerContext := resolveDockerContext(c.DockerContext); dockerContext != "" {
+		args = append(args, "--context", proxyCommandQuote(dockerContext))
+	} else if os.Getenv("DOCKER_HOST") != "" && runtime.GOOS != "windows" {
+		env := []string{"env"}
+		for _, name := range dockerHostEnv {
+			if value := os.Getenv(name); value != "" {
+				env = append(env, proxyCommandQuote(name+"="+value))
+			}
+		}
+		args = append(env, args...)
+	}
+	// ssh expands %-tokens in the ProxyCommand
+	return strings.Replace(strings.Join(args, " "), "%", "%%", -1)
+}
+
+// Quote arg for the shell running the ssh ProxyCommand: sh on unix, cmd on windows
+func proxyCommandQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`&|;<>()*?[]#~!{}") {
+		return arg
+	}
+	if runtime.GOOS == "windows" {
+		return `"` + arg + `"`
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// Options common to ssh and scp: the KDK key, no host key checking (the host key changes on
+// every recreate), and the exec relay when no port is published
+func (c *KdkEnvConfig) sshOptions() []string {
+	options := []string{"-i", c.PrivateKeyPath(), "-o", "StrictHostKeyChecking=no", "-o", "UserKnownHostsFile=/dev/null"}
+	if c.ConfigFile.AppConfig.SocketOnly {
+		options = append(options, "-o", "ProxyCommand="+c.sshProxyCommand())
+	}
+	return options
+}
+
+// Returns the ssh command line
+func (c *KdkEnvConfig) sshArgs() []string {
+	return append([]string{"ssh", c.SSHConnectionString(), "-A", "-p", c.ConfigFile.AppConfig.Port}, c.sshOptions()...)
+}
+
+// Returns the scp command line
+func (c *KdkEnvConfig) scpArgs() []string {
+	return append([]string{"scp", "-P", c.ConfigFile.AppConfig.Port}, c.sshOptions()...)
+}
+
+// Join args into a command line for display, quoting args with spaces
+func commandString(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			arg = "'" + arg + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,39 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestProxyCommandQuote(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cmd quoting differs on windows")
+	}
+
+	for _, test := range [][2]string{
+		{"/usr/local/bin/kdk", "/usr/local/bin/kdk"},
+		{"/Users/dev/My Tools/kdk", "'/Users/dev/My Tools/kdk'"},
+		{"/opt/dev's/kdk", `'/opt/dev'\''s/kdk'`},
+		{"DOCKER_HOST=tcp://10.0.0.1:2376", "DOCKER_HOST=tcp://10.0.0.1:2376"},
+		{"", "''"},
+	} {
+		if quoted := proxyCommandQuote(test[0]); quoted != test[1] {
+			t.Logf("proxyCommandQuote(%q) is %q, expected %q", test[0], quoted, test[1])
+			t.FailNow()
+		}
+	}
+}
@@ -15,9 +15,7 @@
 package kdk
 
 import (
-	"fmt"
 	"os"
-	"strings"
 
 	"github.com/codeskyblue/go-sh"
 	log "github.com/sirupsen/logrus"
@@ -28,19 +26,17 @@ func Ssh(cfg KdkEnvConfig) {
 	log.Info("Connecting to KDK container")
 
 	// If KDK container is not running, start it and provision KDK user.
-	cfg.Start()
+	if err := cfg.Start(); err != nil {
+		log.WithField("error", err).Fatal("Failed to start KDK container")
+	}
 
-	// Build socksString
-	var socksString string
+	// connect to KDK container via ssh, with a socks proxy if configured
+	args := cfg.sshArgs()
 	if cfg.ConfigFile.AppConfig.SocksPort != "" {
-		socksString = "-D " + cfg.ConfigFile.AppConfig.SocksPort
+		args = append(args, "-D", cfg.ConfigFile.AppConfig.SocksPort)
 	}
-
-	// connect to KDK container via ssh
-	commandString := fmt.Sprintf("%s %s", cfg.SSHCommandString(), socksString)
-	log.Infof("executing ssh command: %s", commandString)
-	commandMap := strings.Split(commandString, " ")
-	if err := sh.Command(commandMap[0], commandMap[1:]).SetStdin(os.Stdin).Run(); err != nil {
+	log.Infof("executing ssh command: %s", commandString(args))
+	if err := sh.Command(args[0], args[1:]).SetStdin(os.Stdin).Run(); err != nil {
 		log.WithField("error", err).Fatal("Failed to ssh to KDK container.")
 	}
 
@@ -0,0 +1,122 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Host ~/.ssh/config path
+func (c *KdkEnvConfig) sshConfigPath() string {
+	return filepath.Join(c.Home(), ".ssh", "config")
+}
+
+// Markers delimiting the Host block kdk manages for the environment in ~/.ssh/config
+func (c *KdkEnvConfig) sshConfigMarkers() (begin, end string) {
+	return "# BEGIN kdk " + c.ConfigFile.AppConfig.Name, "# END kdk " + c.ConfigFile.AppConfig.Name
+}
+
+// The ~/.ssh/config Host block of the environment, so that `ssh <container name>` connects to the KDK
+func (c *KdkEnvConfig) sshConfigEntry() string {
+	begin, end := c.sshConfigMarkers()
+	lines := []string{
+		begin,
+		"Host " + c.ContainerName(),
+		"  HostName localhost",
+		"  Port " + c.ConfigFile.AppConfig.Port,
+		"  User " + c.User(),
+		"  IdentityFile " + c.PrivateKeyPath(),
+		"  ForwardAgent yes",
+		"  StrictHostKeyChecking no",
+		"  UserKnownHostsFile /dev/null",
+	}
+	if c.ConfigFile.AppConfig.SocketOnly {
+		lines = append(lines, "  ProxyCommand "+c.sshProxyCommand())
+	}
+	return strings.Join(append(lines, end), "\n") + "\n"
+}
+
+// Replace the environment's Host block in config with entry, or append it.  Returns false if the
+// block is missing and add is false.
+func replaceSshConfigEntry(config, entry, begin, end string, add bool) (string, bool) {
+	start := strings.Index(config, begin+"\n")
+	if start < 0 {
+		if !add {
+			return config, false
+		}
+		if config != "" && !strings.HasSuffix(config, "\n") {
+			config += "\n"
+		}
+		return config + entry, true
+	}
+	stop := strings.Index(config[start:], end)
+	if stop < 0 {
+		return config, false
+	}
+	stop += start + len(end)
+	if stop < len(config) && config[stop] == '\n' {
+		stop++
+	}
+	return config[:start] + entry + config[stop:], true
+}
+
+func (c *KdkEnvConfig) writeSshConfigEntry(add bool) (bool, error) {
+	data, err := ioutil.ReadFile(c.sshConfigPath())
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	begin, end := c.sshConfigMarkers()
+	config, ok := replaceSshConfigEntry(string(data), c.sshConfigEntry(), begin, end, add)
+	if !ok {
+		return false, nil
+	}
+	if config == string(data) {
+		return true, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.sshConfigPath()), 0700); err != nil {
+		return false, err
+	}
+	if err := ioutil.WriteFile(c.sshConfigPath(), []byte(config), 0600); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Write the environment's Host block to ~/.ssh/config, updating it if it exists
+func (c *KdkEnvConfig) WriteSshConfigEntry() error {
+	if _, err := c.writeSshConfigEntry(true); err != nil {
+		return fmt.Errorf("failed to write %s: %v", c.sshConfigPath(), err)
+	}
+	log.Infof("Wrote ssh config entry to %s.  Connect with `ssh %s`", c.sshConfigPath(), c.ContainerName())
+	return nil
+}
+
+// Update the environment's Host block in ~/.ssh/config, if one was written, e.g. after the port changed
+func (c *KdkEnvConfig) refreshSshConfigEntry() {
+	if c.InMemory {
+		return
+	}
+	if updated, err := c.writeSshConfigEntry(false); err != nil {
+		log.WithField("error", err).Warnf("Failed to update ssh config entry in %s", c.sshConfigPath())
+	} else if updated {
+		log.Debugf("Updated ssh config entry in %s", c.sshConfigPath())
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// KDK sshd config drop-in, included by the image sshd_config
+const sshdConfigTarget = "/etc/ssh/sshd_config.d/kdk.conf"
+
+// Syntactically check an sshd config file: each line must be blank, a comment, or
+// "Keyword value" (or "Keyword=value").  Keyword semantics are left to sshd.
+func validateSshdConfig(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("invalid SshdConfig [%s]: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool { return unicode.IsSpace(r) || r == '=' })
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid SshdConfig [%s] line %d: expected \"Keyword value\"", path, lineNum)
+		}
+		for _, r := range fields[0] {
+			if !unicode.IsLetter(r) {
+				return fmt.Errorf("invalid SshdConfig [%s] line %d: invalid keyword [%s]", path, lineNum, fields[0])
+			}
+		}
+	}
+	return scanner.Err()
+}
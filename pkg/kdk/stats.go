@@ -0,0 +1,132 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-units"
+)
+
+// A resource usage sample of the KDK container
+type KdkStats struct {
+	Read        time.Time
+	CPUPercent  float64 // of a single cpu, so up to 100 times the number of cpus
+	MemoryUsage uint64  // excluding the page cache
+	MemoryLimit uint64
+	NetRx       uint64 // bytes received, over all networks
+	NetTx       uint64
+	BlockRead   uint64
+	BlockWrite  uint64
+}
+
+func (s KdkStats) MemoryPercent() float64 {
+	if s.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+}
+
+func (s KdkStats) String() string {
+	return fmt.Sprintf("CPU %.1f%%  MEM %s / %s (%.1f%%)  NET %s / %s  BLOCK %s / %s",
+		s.CPUPercent,
+		units.BytesSize(float64(s.MemoryUsage)), units.BytesSize(float64(s.MemoryLimit)), s.MemoryPercent(),
+		units.HumanSize(float64(s.NetRx)), units.HumanSize(float64(s.NetTx)),
+		units.HumanSize(float64(s.BlockRead)), units.HumanSize(float64(s.BlockWrite)))
+}
+
+// Convert a docker stats sample, computing the cpu percentage as the docker cli does
+func newKdkStats(stats *types.StatsJSON) KdkStats {
+	s := KdkStats{
+		Read:        stats.Read,
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+	}
+	// cgroup v1 counts the page cache as usage
+	if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < s.MemoryUsage {
+		s.MemoryUsage -= cache
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		s.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	for _, network := range stats.Networks {
+		s.NetRx += network.RxBytes
+		s.NetTx += network.TxBytes
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			s.BlockRead += entry.Value
+		case "write":
+			s.BlockWrite += entry.Value
+		}
+	}
+	return s
+}
+
+// Sample the resource usage of the running KDK container, calling handle with each sample.  Without
+// stream, handle is called once, with the second sample of the stream as `docker stats --no-stream`
+// does: the first has no previous cpu sample, so its cpu percentage would average over the container
+// lifetime.  With stream, about every second until handle returns an error or the container stops.
+func (c *KdkEnvConfig) Stats(stream bool, handle func(KdkStats) error) error {
+	if running, err := c.IsRunning(); err != nil {
+		return err
+	} else if !running {
+		return fmt.Errorf("KDK container [%s] is not running", c.ContainerName())
+	}
+	response, err := c.DockerClient.ContainerStats(c.Ctx, c.ContainerName(), true)
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+	var first *types.StatsJSON
+	for {
+		var stats types.StatsJSON
+		if err := decoder.Decode(&stats); err == io.EOF {
+			// the container stopped before a second sample
+			if first != nil {
+				return handle(newKdkStats(first))
+			}
+			return nil
+		} else if err != nil {
+			return wrapDockerError(err)
+		}
+		if !stream && first == nil && stats.PreCPUStats.SystemUsage == 0 {
+			first = &stats
+			continue
+		}
+		if err := handle(newKdkStats(&stats)); err != nil {
+			return err
+		}
+		if !stream {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"math"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestNewKdkStats(t *testing.T) {
+
+	var stats types.StatsJSON
+	stats.PreCPUStats.CPUUsage.TotalUsage = 1000
+	stats.PreCPUStats.SystemUsage = 10000
+	stats.CPUStats.CPUUsage.TotalUsage = 1500
+	stats.CPUStats.SystemUsage = 20000
+	stats.CPUStats.OnlineCPUs = 4
+	stats.MemoryStats.Usage = 300
+	stats.MemoryStats.Limit = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"cache": 100}
+	stats.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 20},
+		"eth1": {RxBytes: 1, TxBytes: 2},
+	}
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: 5}, {Op: "Write", Value: 7}, {Op: "Read", Value: 1}, {Op: "Total", Value: 13},
+	}
+
+	s := newKdkStats(&stats)
+	// 500 of 10000 system time over 4 cpus
+	if math.Abs(s.CPUPercent-20) > 1e-9 {
+		t.Logf("CPUPercent is %v, expected 20", s.CPUPercent)
+		t.FailNow()
+	}
+	if s.MemoryUsage != 200 || s.MemoryLimit != 1000 || math.Abs(s.MemoryPercent()-20) > 1e-9 {
+		t.Logf("Memory is %d / %d (%v%%), expected 200 / 1000 (20%%) excluding the page cache", s.MemoryUsage, s.MemoryLimit, s.MemoryPercent())
+		t.FailNow()
+	}
+	if s.NetRx != 11 || s.NetTx != 22 {
+		t.Logf("Network is %d / %d, expected 11 / 22 over all networks", s.NetRx, s.NetTx)
+		t.FailNow()
+	}
+	if s.BlockRead != 6 || s.BlockWrite != 7 {
+		t.Logf("Block IO is %d / %d, expected 6 / 7", s.BlockRead, s.BlockWrite)
+		t.FailNow()
+	}
+
+	// without a previous sample, or per-cpu usage for the cpu count
+	stats.PreCPUStats = types.CPUStats{}
+	stats.CPUStats.OnlineCPUs = 0
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{750, 750}
+	if s := newKdkStats(&stats); math.Abs(s.CPUPercent-1500.0/20000*2*100) > 1e-9 {
+		t.Logf("CPUPercent without a previous sample is %v", s.CPUPercent)
+		t.FailNow()
+	}
+	stats.CPUStats = types.CPUStats{}
+	if s := newKdkStats(&stats); s.CPUPercent != 0 {
+		t.Logf("CPUPercent without cpu usage is %v, expected 0", s.CPUPercent)
+		t.FailNow()
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A published port of the KDK container
+type PortMapping struct {
+	HostIP        string
+	HostPort      string
+	ContainerPort string
+	Protocol      string
+}
+
+func (p PortMapping) String() string {
+	hostIP := p.HostIP
+	if hostIP == "" {
+		hostIP = "0.0.0.0"
+	}
+	return fmt.Sprintf("%s:%s->%s/%s", hostIP, p.HostPort, p.ContainerPort, p.Protocol)
+}
+
+// Summary of the KDK environment and its container
+type KdkStatus struct {
+	Name          string
+	ContainerName string
+	Image         string
+	State         string // docker container state, or "not created"
+	Ports         []PortMapping
+	ConfigDrift   []string     // config.yaml sections changed since the container was created
+	LabelDrift    []LabelDrift // labels of a container created by another kdk version or user
+}
+
+// Returns the actual published ports of the running KDK container, including host ports assigned by docker
+func (c *KdkEnvConfig) PublishedPorts() ([]PortMapping, error) {
+	inspect, err := c.Inspect()
+	if err != nil {
+		return nil, err
+	}
+	if inspect.State == nil || !inspect.State.Running || inspect.NetworkSettings == nil {
+		return nil, fmt.Errorf("KDK container [%s] is not running", c.ContainerName())
+	}
+
+	var ports []PortMapping
+	for port, bindings := range inspect.NetworkSettings.Ports {
+		for _, binding := range bindings {
+			ports = append(ports, PortMapping{
+				HostIP:        binding.HostIP,
+				HostPort:      binding.HostPort,
+				ContainerPort: port.Port(),
+				Protocol:      port.Proto(),
+			})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].String() < ports[j].String() })
+	return ports, nil
+}
+
+// Returns the status of the KDK environment
+func (c *KdkEnvConfig) Status() (*KdkStatus, error) {
+	status := &KdkStatus{
+		Name:          c.ConfigFile.AppConfig.Name,
+		ContainerName: c.ContainerName(),
+		Image:         c.ImageCoordinates(),
+		State:         "not created",
+	}
+	inspect, err := c.Inspect()
+	if errors.Is(err, ErrContainerNotFound) {
+		return status, nil
+	} else if err != nil {
+		return nil, err
+	}
+	status.Image = inspect.Config.Image
+	if _, status.ConfigDrift, err = c.NeedsRecreate(); err != nil {
+		return nil, err
+	}
+	if status.LabelDrift, err = c.ReconcileLabels(); err != nil {
+		return nil, err
+	}
+	if inspect.State != nil {
+		status.State = inspect.State.Status
+		if inspect.State.Running {
+			if status.Ports, err = c.PublishedPorts(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return status, nil
+}
+
+func (s *KdkStatus) String() string {
+	var ports []string
+	for _, port := range s.Ports {
+		ports = append(ports, port.String())
+	}
+	out := fmt.Sprintf("Name:      %s\nContainer: %s\nImage:     %s\nState:     %s\nPorts:     %s\n",
+		s.Name, s.ContainerName, s.Image, s.State, strings.Join(ports, ", "))
+	if len(s.ConfigDrift) > 0 {
+		out += fmt.Sprintf("Config:    changed (%s), run `kdk recreate` to apply\n", strings.Join(s.ConfigDrift, ", "))
+	}
+	if len(s.LabelDrift) > 0 {
+		var drift []string
+		for _, d := range s.LabelDrift {
+			drift = append(drift, d.String())
+		}
+		out += fmt.Sprintf("Labels:    %s, run `kdk recreate` to update\n", strings.Join(drift, ", "))
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ~/.kdk/<name>/status.json, which monitoring can poll without docker API access.  kdk rewrites
+// it on lifecycle transitions, and removes it with the container.
+const statusFile = "status.json"
+
+type KdkStatusFile struct {
+	Name        string
+	State       string // "starting", or the docker container state once started (e.g. running, exited)
+	Port        string
+	ContainerID string `json:",omitempty"`
+	StartedAt   string `json:",omitempty"` // RFC3339
+	UpdatedAt   string // RFC3339
+}
+
+func (c *KdkEnvConfig) StatusFilePath() string {
+	return filepath.Join(c.ConfigDir(), statusFile)
+}
+
+// Write status.json with state, or the state of the container when state is "".  Monitoring is
+// best effort, so failures are only logged.
+func (c *KdkEnvConfig) writeStatusFile(state string) {
+	if c.InMemory {
+		return
+	}
+	status := KdkStatusFile{
+		Name:      c.ConfigFile.AppConfig.Name,
+		State:     state,
+		Port:      c.ConfigFile.AppConfig.Port,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if inspect, err := c.Inspect(); err == nil {
+		status.ContainerID = inspect.ID
+		if inspect.State != nil {
+			if status.State == "" {
+				status.State = inspect.State.Status
+			}
+			if inspect.State.Running {
+				status.StartedAt = inspect.State.StartedAt
+			}
+		}
+	}
+	if status.State == "" {
+		status.State = "not created"
+	}
+
+	data, err := json.MarshalIndent(&status, "", "  ")
+	if err != nil {
+		log.WithField("error", err).Debug("Failed to marshal KDK status file")
+		return
+	}
+	// write and rename, so that pollers never read a partial file
+	tmp := c.StatusFilePath() + ".tmp"
+	if err := mkdirPolicy(c.ConfigDir()); err != nil {
+		log.WithField("error", err).Warnf("Failed to write KDK status file %s", c.StatusFilePath())
+		return
+	}
+	if err := writeFilePolicy(tmp, append(data, '\n')); err != nil {
+		log.WithField("error", err).Warnf("Failed to write KDK status file %s", c.StatusFilePath())
+		return
+	}
+	if err := os.Rename(tmp, c.StatusFilePath()); err != nil {
+		log.WithField("error", err).Warnf("Failed to write KDK status file %s", c.StatusFilePath())
+	}
+}
+
+func (c *KdkEnvConfig) removeStatusFile() {
+	if c.InMemory {
+		return
+	}
+	if err := os.Remove(c.StatusFilePath()); err != nil && !os.IsNotExist(err) {
+		log.WithField("error", err).Warnf("Failed to remove KDK status file %s", c.StatusFilePath())
+	}
+}
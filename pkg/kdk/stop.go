@@ -0,0 +1,69 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/docker/docker/api/types/container"
+	log "github.com/sirupsen/logrus"
+)
+
+// Seconds a stopping KDK container is given to flush and exit before it is killed.  The docker
+// daemon also honors this when it stops containers for a host shutdown.
+const DefaultStopTimeout = 30
+
+// Signal names (with or without the SIG prefix, e.g. SIGRTMIN+3 for systemd) or numbers
+var stopSignal = regexp.MustCompile(`^(SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?$|^[0-9]+$`)
+
+// systemd as pid 1, which the stock KDK image runs, shuts down on SIGRTMIN+3 rather than SIGTERM
+const systemdStopSignal = "SIGRTMIN+3"
+
+// Without a configured StopSignal, stop containers running systemd with systemdStopSignal, so that
+// they shut down within the StopTimeout rather than being killed
+func (c *KdkEnvConfig) defaultStopSignal(config *container.Config) {
+	if config.StopSignal != "" {
+		return
+	}
+	command := c.containerCommand(config.Entrypoint, config.Cmd, config.Image)
+	if len(command) > 0 && path.Base(command[0]) == "systemd" {
+		config.StopSignal = systemdStopSignal
+	}
+}
+
+func validateStopSignal(signal string) error {
+	if signal != "" && !stopSignal.MatchString(signal) {
+		return fmt.Errorf("invalid StopSignal [%s]: expected a signal name (e.g. SIGTERM) or number", signal)
+	}
+	return nil
+}
+
+func validateStopTimeout(timeout int) error {
+	if timeout < 0 {
+		return fmt.Errorf("invalid StopTimeout [%d]: must not be negative", timeout)
+	}
+	return nil
+}
+
+// Gracefully stop the container with its StopSignal, killing it after its StopTimeout.  Removing
+// a running container (e.g. on destroy) would otherwise kill it without warning.
+func (c *KdkEnvConfig) stopContainer(id string) {
+	log.Infof("Stopping KDK container [%s]", c.ContainerName())
+	if err := c.DockerClient.ContainerStop(c.Ctx, id, nil); err != nil {
+		log.WithField("error", wrapDockerError(err)).Warn("Failed to stop KDK container.  Removing it anyway")
+	}
+}
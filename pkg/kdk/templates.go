@@ -0,0 +1,156 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+// A starting point for `kdk init --template`.  Explicit init flags take precedence over Flags.
+type Template struct {
+	Description string
+	Flags       map[string]string `json:",omitempty"` // init flag values, e.g. cache-volumes: go
+	Env         []string          `json:",omitempty"` // KEY=VALUE container environment
+	Mounts      []string          `json:",omitempty"` // ~/host:~/container bind mounts, skipped when the host path is missing
+}
+
+// Built-in templates.  ~/.kdk/templates.yaml may override these, or add more.
+var templates = map[string]Template{
+	"go": {
+		Description: "Go modules development",
+		Flags:       map[string]string{"cache-volumes": "go"},
+		Env:         []string{"GO111MODULE=on"},
+		Mounts:      []string{"~/go/src:/go/src"},
+	},
+	"node": {
+		Description: "Node.js development with npm and yarn",
+		Flags:       map[string]string{"cache-volumes": "npm,yarn"},
+		Env:         []string{"NODE_ENV=development"},
+		Mounts:      []string{"~/Projects:~/Projects"},
+	},
+	"python": {
+		Description: "Python development with pip",
+		Flags:       map[string]string{"cache-volumes": "pip"},
+		Env:         []string{"PYTHONDONTWRITEBYTECODE=1", "PIP_DISABLE_PIP_VERSION_CHECK=1"},
+		Mounts:      []string{"~/Projects:~/Projects"},
+	},
+	"k8s": {
+		Description: "Kubernetes operations with the host kubeconfig",
+		Flags:       map[string]string{"kubeconfig": "~/.kube/config"},
+		Env:         []string{"KUBE_EDITOR=vim"},
+		Mounts:      []string{"~/Projects:~/Projects"},
+	},
+}
+
+const templatesFile = "templates.yaml"
+
+// Path of the user templates, ~/.kdk/templates.yaml
+func (c *KdkEnvConfig) TemplatesPath() string {
+	return filepath.Join(c.ConfigRootDir(), templatesFile)
+}
+
+// Built-in templates, overridden by those of ~/.kdk/templates.yaml
+func (c *KdkEnvConfig) Templates() (map[string]Template, error) {
+	all := map[string]Template{}
+	for name, template := range templates {
+		all[name] = template
+	}
+	data, err := ioutil.ReadFile(c.TemplatesPath())
+	if os.IsNotExist(err) {
+		return all, nil
+	} else if err != nil {
+		return nil, err
+	}
+	user := map[string]Template{}
+	if err := yaml.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", c.TemplatesPath(), err)
+	}
+	for name, template := range user {
+		all[name] = template
+	}
+	return all, nil
+}
+
+// Sorted template names
+func (c *KdkEnvConfig) TemplateNames() ([]string, error) {
+	all, err := c.Templates()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// The named template
+func (c *KdkEnvConfig) Template(name string) (Template, error) {
+	all, err := c.Templates()
+	if err != nil {
+		return Template{}, err
+	}
+	template, ok := all[name]
+	if !ok {
+		names, _ := c.TemplateNames()
+		return Template{}, fmt.Errorf("invalid Template [%s]: must be one of %s", name, strings.Join(names, ", "))
+	}
+	for _, env := range template.Env {
+		if !strings.Contains(env, "=") {
+			return Template{}, fmt.Errorf("invalid Template [%s]: env [%s] must be KEY=VALUE", name, env)
+		}
+	}
+	return template, nil
+}
+
+// Expand a leading ~ of a template mount path to home
+func expandTemplatePath(p, home string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return home + p[1:]
+	}
+	return p
+}
+
+// Bind mounts of the template whose host paths exist
+func (c *KdkEnvConfig) templateMounts(template Template) ([]mount.Mount, error) {
+	var mounts []mount.Mount
+	for _, spec := range template.Mounts {
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid template mount [%s]: must be host:container", spec)
+		}
+		source := filepath.FromSlash(expandTemplatePath(parts[0], filepath.ToSlash(c.Home())))
+		target := path.Clean(expandTemplatePath(parts[1], c.ContainerHome()))
+		if _, err := os.Stat(source); err != nil {
+			log.Infof("Skipping template mount of missing host directory %s", source)
+			continue
+		}
+		log.Infof("Mounting host directory %s at %s", source, target)
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: source, Target: target,
+			Consistency: mount.ConsistencyCached})
+	}
+	return mounts, nil
+}
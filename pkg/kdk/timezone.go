@@ -0,0 +1,60 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+const localtimePath = "/etc/localtime"
+
+// Resolve the host /etc/localtime, which is usually a symlink into the zoneinfo database.
+// Returns the zoneinfo file to mount, and the zone name (e.g. America/New_York) for TZ.
+func hostTimezone() (zoneinfo string, tz string, err error) {
+	zoneinfo, err = filepath.EvalSymlinks(localtimePath)
+	if err != nil {
+		return "", "", err
+	}
+	tz = os.Getenv("TZ")
+	if i := strings.Index(zoneinfo, "zoneinfo/"); tz == "" && i >= 0 {
+		tz = zoneinfo[i+len("zoneinfo/"):]
+	}
+	return zoneinfo, tz, nil
+}
+
+// Mount and environment for the host timezone and configured locale
+func (c *KdkEnvConfig) timezoneLocale() (mounts []mount.Mount, env []string) {
+	if c.ConfigFile.AppConfig.SyncTimezone {
+		// Docker bind mounts a symlink source as the link itself, so mount the resolved file
+		if zoneinfo, tz, err := hostTimezone(); err != nil {
+			log.WithField("error", err).Warnf("Failed to resolve host %s.  Timezone not synced", localtimePath)
+		} else {
+			mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: zoneinfo, Target: localtimePath, ReadOnly: true})
+			if tz != "" {
+				env = append(env, "TZ="+tz)
+			}
+			log.Infof("Syncing host timezone [%s]", tz)
+		}
+	}
+	if c.ConfigFile.AppConfig.Locale != "" {
+		env = append(env, "LANG="+c.ConfigFile.AppConfig.Locale)
+	}
+	return mounts, env
+}
@@ -15,30 +15,46 @@
 package kdk
 
 import (
+	"errors"
+	"fmt"
 	"runtime"
+	"strconv"
 
 	"github.com/cisco-sso/kdk/pkg/keybase"
 	"github.com/cisco-sso/kdk/pkg/prompt"
+	"github.com/cisco-sso/kdk/pkg/utils"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	log "github.com/sirupsen/logrus"
 )
 
-func Up(cfg KdkEnvConfig) (err error) {
+// Number of ports to try when the configured KDK port is taken
+const portBindAttempts = 3
 
-	if runtime.GOOS == "windows" {
+// Create and start the KDK container, retrying on another port if the configured one is taken.  An
+// exited KDK container is restarted or removed as answered on stdin, even for an InMemory config, so
+// callers embedding kdk should remove exited containers first.
+func Up(cfg *KdkEnvConfig) (err error) {
+	if err := cfg.runHooks("pre-start"); err != nil {
+		return err
+	}
+	cfg.refreshKubeconfig()
+	cfg.writeStatusFile("starting")
+
+	if runtime.GOOS == "windows" && !cfg.InMemory {
 		if err := keybase.StartMirror(cfg.ConfigRootDir()); err != nil {
-			log.WithField("error", err).Fatal("Failed to start keybase mirror")
-			return err
+			return fmt.Errorf("failed to start keybase mirror: %w", err)
 		}
 	}
 
 	containers, err := cfg.DockerClient.ContainerList(cfg.Ctx, types.ContainerListOptions{All: true})
 	if err != nil {
-		log.WithField("error", err).Fatal("Failed to list docker containers")
+		return wrapDockerError(err)
 	}
 	for _, container := range containers {
 		for _, name := range container.Names {
-			if name == "/"+cfg.ConfigFile.AppConfig.Name {
+			if name == "/"+cfg.ContainerName() {
 				if container.State == "exited" {
 					log.Infof("An exited KDK container exists")
 					p := prompt.Prompt{
@@ -48,8 +64,7 @@ func Up(cfg KdkEnvConfig) (err error) {
 					}
 					if result, err := p.Run(); err == nil && result == "y" {
 						log.Info("Restarting exited KDK container")
-						containerStart(cfg, container.ID)
-						return nil
+						return containerStart(*cfg, container.ID)
 					} else {
 						p := prompt.Prompt{
 							Text:     "Delete exited KDK container? [y/n] ",
@@ -57,45 +72,142 @@ func Up(cfg KdkEnvConfig) (err error) {
 							Validate: prompt.ValidateYorN,
 						}
 						if result, err := p.Run(); err != nil || result == "n" {
-							log.Fatal("KDK exited image deletion canceled or invalid input.")
+							return errors.New("KDK exited container deletion canceled or invalid input")
 						}
 						log.Info("Removing exited KDK container")
 						if err := cfg.DockerClient.ContainerRemove(cfg.Ctx, container.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
-							log.WithField("error", err).Fatalf("Failed to remove exited KDK container [%s]", container.ImageID)
+							return fmt.Errorf("failed to remove exited KDK container [%s]: %w", container.ID, wrapDockerError(err))
 						}
 					}
 				}
 			}
 		}
 	}
-	containerID, err := containerCreate(cfg)
-	if err != nil {
-		log.WithField("error", err).Fatal("Failed to create KDK container")
-	} else {
-		if err := containerStart(cfg, containerID); err != nil {
-			log.WithField("error", err).Fatal("Failed to start KDK container")
+
+	// Without a published port there is nothing to retry
+	if cfg.ConfigFile.AppConfig.SocketOnly {
+		containerID, err := containerCreate(*cfg)
+		if err != nil {
+			return err
 		}
+		return containerStart(*cfg, containerID)
 	}
+
+	// The configured port may have been taken since init.  Retry with a fresh port if so.
+	port, _ := strconv.Atoi(cfg.ConfigFile.AppConfig.Port)
+	_, err = utils.BindWithRetry(port, portBindAttempts, func(port int) error {
+		if strconv.Itoa(port) != cfg.ConfigFile.AppConfig.Port {
+			log.Warnf("KDK port [%s] is in use.  Retrying with port [%d]", cfg.ConfigFile.AppConfig.Port, port)
+			if err := cfg.setPort(strconv.Itoa(port)); err != nil {
+				return err
+			}
+		}
+		containerID, err := containerCreate(*cfg)
+		if err != nil {
+			return err
+		}
+		if err := containerStart(*cfg, containerID); err != nil {
+			if errors.Is(err, ErrPortInUse) {
+				// port bindings are fixed at create time, so the container must be recreated
+				if err := cfg.DockerClient.ContainerRemove(cfg.Ctx, containerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+					return fmt.Errorf("failed to remove KDK container [%s] to retry on another port: %w", containerID, wrapDockerError(err))
+				}
+			}
+			return err
+		}
+		return nil
+	}, func(err error) bool {
+		return errors.Is(err, ErrPortInUse)
+	})
+	if err == nil {
+		// Keep a generated ssh alias current with the port
+		cfg.refreshSshConfigEntry()
+	}
+	return err
+}
+
+// Update the KDK host port and save it to config.yaml.  The port is saved even to a Locked
+// config, since ssh must connect to the port the container was created with.
+func (c *KdkEnvConfig) setPort(port string) error {
+	c.ConfigFile.AppConfig.Port = port
+	for containerPort, bindings := range c.ConfigFile.HostConfig.PortBindings {
+		if containerPort.Port() != "2022" {
+			continue
+		}
+		for i := range bindings {
+			bindings[i].HostPort = port
+		}
+	}
+	if !c.InMemory {
+		if err := c.saveConfig(); err != nil {
+			return err
+		}
+	}
+	log.Infof("KDK port changed to [%s].  Connect with: %s", port, c.SSHCommandString())
 	return nil
 }
+
 func containerCreate(cfg KdkEnvConfig) (string, error) {
-	containerCreateResp, err := cfg.DockerClient.ContainerCreate(
-		cfg.Ctx,
-		cfg.ConfigFile.ContainerConfig,
-		cfg.ConfigFile.HostConfig,
-		nil,
-		cfg.ConfigFile.AppConfig.Name,
-	)
+	if err := cfg.validateImageConfig(); err != nil {
+		return "", err
+	}
+	effective, err := cfg.EffectiveConfig()
 	if err != nil {
 		return "", err
 	}
+	cfg.defaultStopSignal(effective.ContainerConfig)
+	if useInit := effective.HostConfig.Init; useInit != nil && *useInit {
+		*useInit = cfg.allowInit(effective.ContainerConfig.Entrypoint, effective.ContainerConfig.Cmd, effective.ContainerConfig.Image)
+	}
+
+	// The docker client predates the ContainerCreate platform argument.  AppConfig.Platform selects
+	//   the variant at pull time, and the container runs the pulled image.
+	var containerCreateResp container.ContainerCreateCreatedBody
+	err = retryDocker("Create KDK container", func() (err error) {
+		containerCreateResp, err = cfg.DockerClient.ContainerCreate(
+			cfg.Ctx,
+			effective.ContainerConfig,
+			effective.HostConfig,
+			cfg.networkingConfig(),
+			cfg.ContainerName(),
+		)
+		// Create is not idempotent: the daemon may have created the container before the response
+		//   was lost, and a retry would then fail with a name conflict.  Use that container instead.
+		if err != nil && isTransientDockerError(err) {
+			if existing, inspectErr := cfg.DockerClient.ContainerInspect(cfg.Ctx, cfg.ContainerName()); inspectErr == nil {
+				log.WithField("error", err).Warnf("Create KDK container failed, but the daemon created it [%s]", existing.ID)
+				containerCreateResp.ID = existing.ID
+				return nil
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return "", wrapImageError(err)
+	}
 	return containerCreateResp.ID, nil
 }
 
 func containerStart(cfg KdkEnvConfig, containerID string) (err error) {
-	if err := cfg.DockerClient.ContainerStart(cfg.Ctx, containerID, types.ContainerStartOptions{}); err != nil {
-		return err
+	err = retryDocker("Start KDK container", func() error {
+		return cfg.DockerClient.ContainerStart(cfg.Ctx, containerID, types.ContainerStartOptions{})
+	})
+	if err != nil {
+		return wrapDockerError(err)
 	}
 	log.Info("Successfully started KDK container")
 	return nil
 }
+
+// Endpoint settings for the user-defined network, if any.  Aliases make the KDK discoverable
+// by other containers on the network.
+func (c *KdkEnvConfig) networkingConfig() *network.NetworkingConfig {
+	if c.ConfigFile.AppConfig.Network == "" {
+		return nil
+	}
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			c.ConfigFile.AppConfig.Network: {Aliases: c.ConfigFile.AppConfig.NetworkAliases},
+		},
+	}
+}
@@ -53,7 +53,7 @@ func WarnIfUpdateAvailable(cfg *KdkEnvConfig) {
 			"Some KDK components are out of date.",
 			"  Latest Version:                      " + latestReleaseVersion,
 			"  Binary Version:                      " + Version,
-			"  Image Tag:                           " + cfg.ConfigFile.AppConfig.ImageTag,
+			"  Image Tag:                           " + cfg.ImageTag(),
 			"  Container Present at Config Version: " + strconv.FormatBool(!needsUpdateImage(cfg)),
 			"",
 			"Please upgrade the KDK with the commands:",
@@ -77,8 +77,9 @@ func needsUpdateImage(cfg *KdkEnvConfig) bool {
 
 // check if kdk config needs to be updated
 func needsUpdateConfig(cfg *KdkEnvConfig) bool {
-	if cfg.ConfigFile.AppConfig.ImageTag != latestReleaseVersion ||
-		cfg.ConfigFile.ContainerConfig.Image != cfg.ImageCoordinates() ||
+	// A per-platform ImageTags config resolves ContainerConfig.Image on each host at create time
+	if cfg.ImageTag() != latestReleaseVersion ||
+		(len(cfg.ConfigFile.AppConfig.ImageTags) == 0 && cfg.ConfigFile.ContainerConfig.Image != cfg.ImageCoordinates()) ||
 		cfg.ConfigFile.ContainerConfig.Labels["kdk"] != latestReleaseVersion {
 		return true
 	}
@@ -246,7 +247,7 @@ func updateConfig(cfg *KdkEnvConfig) error {
 		return err
 	}
 
-	err = ioutil.WriteFile(cfg.ConfigPath(), y, 0600)
+	err = cfg.writeConfig(y)
 	if err != nil {
 		log.WithField("error", err).Error("Failed to write new config file")
 		return err
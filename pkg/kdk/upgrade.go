@@ -0,0 +1,84 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	log "github.com/sirupsen/logrus"
+)
+
+// Backfill AppConfig fields added since config.yaml was written with their defaults, and rewrite it.
+// Only fields missing from config.yaml are backfilled: a zero value present in the file (e.g.
+// Bootstrap: false) was chosen deliberately.  Returns the names of the backfilled fields.
+func (c *KdkEnvConfig) UpgradeConfig(defaults AppConfig) ([]string, error) {
+	data, err := c.readConfig()
+	if err != nil {
+		return nil, wrapError(ErrConfigNotFound, err)
+	}
+	var raw struct {
+		AppConfig map[string]interface{}
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	// Missing keys keep their default through the unmarshal
+	name := c.ConfigFile.AppConfig.Name
+	c.ConfigFile.AppConfig = defaults
+	if err := yaml.Unmarshal(data, &c.ConfigFile); err != nil {
+		return nil, err
+	}
+	c.ConfigFile.AppConfig.Name = name
+
+	var backfilled []string
+	t := reflect.TypeOf(c.ConfigFile.AppConfig)
+	v := reflect.ValueOf(c.ConfigFile.AppConfig)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "Name" || v.Field(i).IsZero() || hasKey(raw.AppConfig, jsonName(field)) {
+			continue
+		}
+		log.Infof("Backfilled AppConfig.%s with default [%v]", field.Name, v.Field(i).Interface())
+		backfilled = append(backfilled, field.Name)
+	}
+	if len(backfilled) == 0 {
+		return nil, nil
+	}
+	if err := c.validateConfig(); err != nil {
+		return nil, err
+	}
+	return backfilled, c.SaveKdkConfig()
+}
+
+// The config.yaml key of a struct field, through its json tag
+func jsonName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// Whether m has key, case-insensitively as encoding/json matches keys
+func hasKey(m map[string]interface{}, key string) bool {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,391 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
+	"github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+)
+
+// Parse and normalize the KDK host port, which must be an integer in 1-65535
+func normalizePort(port string) (string, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(port))
+	if err != nil {
+		return "", fmt.Errorf("invalid Port [%s]: must be an integer", port)
+	}
+	if p < 1 || p > 65535 {
+		return "", fmt.Errorf("invalid Port [%s]: must be between 1 and 65535", port)
+	}
+	if p < 1024 {
+		log.Warnf("Port [%d] is a privileged port and may require elevated permissions to bind", p)
+	}
+	return strconv.Itoa(p), nil
+}
+
+// Validate and normalize the AppConfig port fields in place
+func (c *KdkEnvConfig) normalizePorts() error {
+	port, err := normalizePort(c.ConfigFile.AppConfig.Port)
+	if err != nil {
+		return err
+	}
+	c.ConfigFile.AppConfig.Port = port
+	return nil
+}
+
+// Expand a leading ~ and environment variables in a mount source path, e.g. as hand
+// edited into config.yaml.  Paths without either are returned untouched.  A missing expanded path
+// only warns, so that the config still loads, e.g. for `kdk init` or `kdk destroy`.
+// checkMountSources fails the container create instead.
+func expandMountSource(source string) (string, error) {
+	if !strings.HasPrefix(source, "~") && !strings.Contains(source, "$") {
+		return source, nil
+	}
+	expanded, err := homedir.Expand(os.ExpandEnv(source))
+	if err != nil {
+		return "", fmt.Errorf("invalid mount Source [%s]: %v", source, err)
+	}
+	if _, err := os.Stat(expanded); err != nil {
+		log.Warnf("Mount Source [%s]: expanded path [%s] does not exist.  The KDK container can't be created until it does", source, expanded)
+	}
+	return expanded, nil
+}
+
+// Whether the docker daemon runs on this host, so that bind mount sources are local paths
+func (c *KdkEnvConfig) localDaemon() bool {
+	return c.DockerClient == nil || strings.HasPrefix(c.DockerClient.DaemonHost(), "unix://") || strings.HasPrefix(c.DockerClient.DaemonHost(), "npipe://")
+}
+
+// Bind mount sources must exist at create time.  The sources of a remote docker daemon are on
+// its host, so are not checked.
+func (c *KdkEnvConfig) checkMountSources(hostConfig *container.HostConfig) error {
+	if !c.localDaemon() {
+		return nil
+	}
+	for _, m := range hostConfig.Mounts {
+		if m.Type != mount.TypeBind {
+			continue
+		}
+		if _, err := os.Stat(m.Source); err != nil {
+			return fmt.Errorf("invalid mount Source [%s]: %v", m.Source, err)
+		}
+	}
+	return nil
+}
+
+// Expand the HostConfig bind mount sources in place
+func (c *KdkEnvConfig) normalizeMounts() error {
+	if c.ConfigFile.HostConfig == nil {
+		return nil
+	}
+	for i, m := range c.ConfigFile.HostConfig.Mounts {
+		if m.Type != mount.TypeBind {
+			continue
+		}
+		source, err := expandMountSource(m.Source)
+		if err != nil {
+			return err
+		}
+		c.ConfigFile.HostConfig.Mounts[i].Source = source
+	}
+	return nil
+}
+
+// Validate the docker container and host configs, which may have been hand edited
+func (c *KdkEnvConfig) validateConfig() error {
+	if containerConfig := c.ConfigFile.ContainerConfig; containerConfig != nil {
+		if err := validateDomainname(containerConfig.Domainname); err != nil {
+			return err
+		}
+	}
+	if hostConfig := c.ConfigFile.HostConfig; hostConfig != nil {
+		if hostConfig.AutoRemove && !(hostConfig.RestartPolicy.Name == "" || hostConfig.RestartPolicy.Name == "no") {
+			return fmt.Errorf("invalid HostConfig: AutoRemove is incompatible with RestartPolicy [%s]", hostConfig.RestartPolicy.Name)
+		}
+		if err := validateCgroupParent(hostConfig.CgroupParent); err != nil {
+			return err
+		}
+		if err := validateLogDriver(hostConfig.LogConfig.Type); err != nil {
+			return err
+		}
+		if err := validateUsernsMode(hostConfig.UsernsMode); err != nil {
+			return err
+		}
+		if err := validateMemory(hostConfig); err != nil {
+			return err
+		}
+		if err := validateCpuset(hostConfig.CpusetCpus, 0); err != nil {
+			return err
+		}
+		if err := validateCpuShares(hostConfig.CPUShares); err != nil {
+			return err
+		}
+		if err := validateDnsSearch(hostConfig.DNSSearch); err != nil {
+			return err
+		}
+	}
+	if err := c.validateImageConfig(); err != nil {
+		return err
+	}
+	if err := validatePlatform(c.ConfigFile.AppConfig.Platform); err != nil {
+		return err
+	}
+	if err := validateNetworkAliases(c.ConfigFile.AppConfig.Network, c.ConfigFile.AppConfig.NetworkAliases); err != nil {
+		return err
+	}
+	if err := validateSidecars(c.ConfigFile.AppConfig.Network, c.ConfigFile.AppConfig.Sidecars); err != nil {
+		return err
+	}
+	if err := validateIdleTimeout(c.ConfigFile.AppConfig.IdleTimeout, c.bootstrap()); err != nil {
+		return err
+	}
+	if err := validatePersistHome(c.ConfigFile.AppConfig.PersistHome); err != nil {
+		return err
+	}
+	if err := validateCacheVolumes(c.ConfigFile.AppConfig.CacheVolumes); err != nil {
+		return err
+	}
+	if err := validateKeyProvider(c.ConfigFile.AppConfig.KeyProvider); err != nil {
+		return err
+	}
+	if err := validateProxy(c.ConfigFile.AppConfig.Proxy); err != nil {
+		return err
+	}
+	for _, source := range c.ConfigFile.AppConfig.AuthorizedKeySources {
+		if err := validateAuthorizedKeySource(source); err != nil {
+			return err
+		}
+	}
+	if err := validateRemoteSync(c.ConfigFile.AppConfig.RemoteSync); err != nil {
+		return err
+	}
+	if err := validateMountProfile(c.ConfigFile.AppConfig.MountProfile); err != nil {
+		return err
+	}
+	if err := validateStopSignal(c.ConfigFile.AppConfig.StopSignal); err != nil {
+		return err
+	}
+	if err := validateStopTimeout(c.ConfigFile.AppConfig.StopTimeout); err != nil {
+		return err
+	}
+	if err := validateBuildArgs(c.ConfigFile.AppConfig.BuildArgs); err != nil {
+		return err
+	}
+	return nil
+}
+
+// A cgroup parent, when provided, must be a non-blank name without whitespace
+func validateCgroupParent(cgroupParent string) error {
+	if cgroupParent != "" && (strings.TrimSpace(cgroupParent) == "" || strings.ContainsAny(cgroupParent, " \t\n")) {
+		return fmt.Errorf("invalid CgroupParent [%s]: must be a non-empty name without whitespace", cgroupParent)
+	}
+	return nil
+}
+
+// RFC 1123 DNS label
+var dnsLabel = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9]{0,61}[A-Za-z0-9])?$`)
+
+// Logging drivers built into docker.  Empty uses the docker daemon default
+var logDrivers = []string{"", "none", "local", "json-file", "syslog", "journald", "gelf", "fluentd",
+	"awslogs", "splunk", "etwlogs", "gcplogs", "logentries"}
+
+// A log driver is a docker built-in driver, or a logging plugin, e.g. grafana/loki-docker-driver:latest
+// or its alias.  Unknown names only warn, since the installed plugins are known to the daemon only.
+func validateLogDriver(driver string) error {
+	for _, known := range logDrivers {
+		if driver == known {
+			return nil
+		}
+	}
+	if strings.TrimSpace(driver) != driver || strings.ContainsAny(driver, " \t\n") {
+		return fmt.Errorf("invalid LogDriver [%s]: must not contain whitespace", driver)
+	}
+	if !strings.ContainsAny(driver, "/:") {
+		log.Warnf("LogDriver [%s] is not a docker built-in driver (%s).  It must be an installed logging plugin", driver, strings.Join(logDrivers[1:], ", "))
+	}
+	return nil
+}
+
+// Whether domain is dot separated DNS labels (e.g. dev.internal)
+func isDomainName(domain string) bool {
+	for _, label := range strings.Split(domain, ".") {
+		if !dnsLabel.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// A domain name, when provided, must be dot separated DNS labels (e.g. dev.internal)
+func validateDomainname(domainname string) error {
+	if domainname == "" {
+		return nil
+	}
+	if len(domainname) > 253 {
+		return fmt.Errorf("invalid Domainname [%s]: must be at most 253 characters", domainname)
+	}
+	if !isDomainName(domainname) {
+		return fmt.Errorf("invalid Domainname [%s]: must be dot separated DNS labels of letters, digits, and hyphens", domainname)
+	}
+	return nil
+}
+
+// DNS search domains must be domain names, or "." for no search domains
+func validateDnsSearch(domains []string) error {
+	for _, domain := range domains {
+		if domain == "." && len(domains) == 1 {
+			continue
+		}
+		if len(domain) > 253 || !isDomainName(domain) {
+			return fmt.Errorf("invalid DnsSearch [%s]: must be dot separated DNS labels of letters, digits, and hyphens", domain)
+		}
+	}
+	return nil
+}
+
+// Whether network is user-defined.  Docker resolves aliases only on user-defined networks, not on
+// the predefined bridge, host, and none networks.
+func isUserDefinedNetwork(network string) bool {
+	switch network {
+	case "", "bridge", "host", "none", "default":
+		return false
+	}
+	return true
+}
+
+// Network aliases must be DNS labels, and require a user-defined network to apply to
+func validateNetworkAliases(network string, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	if !isUserDefinedNetwork(network) {
+		return fmt.Errorf("invalid NetworkAliases: a user-defined Network is required, got [%s]", network)
+	}
+	for _, alias := range aliases {
+		if !dnsLabel.MatchString(alias) {
+			return fmt.Errorf("invalid NetworkAliases [%s]: must be a DNS label of letters, digits, and hyphens", alias)
+		}
+	}
+	return nil
+}
+
+// A user namespace mode is "" (the daemon default) or "host", which opts out of the daemon's
+// --userns-remap.  Remapping itself is a daemon setting, not a container one.
+func validateUsernsMode(mode container.UsernsMode) error {
+	switch mode {
+	case "", "host":
+		return nil
+	}
+	return fmt.Errorf("invalid UsernsMode [%s]: must be host, or empty for the daemon default", mode)
+}
+
+// Parse the human readable AppConfig memory limits to bytes
+func (c *KdkEnvConfig) memoryLimits() (memory int64, memorySwap int64, err error) {
+	if c.ConfigFile.AppConfig.Memory != "" {
+		if memory, err = units.RAMInBytes(c.ConfigFile.AppConfig.Memory); err != nil {
+			return 0, 0, fmt.Errorf("invalid Memory [%s]: %v", c.ConfigFile.AppConfig.Memory, err)
+		}
+	}
+	switch c.ConfigFile.AppConfig.MemorySwap {
+	case "":
+	case "-1":
+		memorySwap = -1
+	default:
+		if memorySwap, err = units.RAMInBytes(c.ConfigFile.AppConfig.MemorySwap); err != nil {
+			return 0, 0, fmt.Errorf("invalid MemorySwap [%s]: %v", c.ConfigFile.AppConfig.MemorySwap, err)
+		}
+	}
+	return memory, memorySwap, nil
+}
+
+// MemorySwap is the memory plus swap limit, so must be at least Memory, or -1 for unlimited swap
+func validateMemory(hostConfig *container.HostConfig) error {
+	if hostConfig.OomScoreAdj < -1000 || hostConfig.OomScoreAdj > 1000 {
+		return fmt.Errorf("invalid OomScoreAdj [%d]: must be between -1000 and 1000", hostConfig.OomScoreAdj)
+	}
+	if hostConfig.MemorySwap > 0 {
+		if hostConfig.Memory <= 0 {
+			return errors.New("invalid MemorySwap: requires a Memory limit")
+		}
+		if hostConfig.MemorySwap < hostConfig.Memory {
+			return fmt.Errorf("invalid MemorySwap [%d]: must be at least Memory [%d]", hostConfig.MemorySwap, hostConfig.Memory)
+		}
+	}
+	if hostConfig.OomKillDisable != nil && *hostConfig.OomKillDisable && hostConfig.Memory <= 0 {
+		log.Warn("OomKillDisable without a Memory limit may let the KDK exhaust host memory")
+	}
+	return nil
+}
+
+// A KDK name is used as the container name and as a directory under ~/.kdk
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("invalid Name: must not be empty")
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." || strings.TrimSpace(name) != name {
+		return fmt.Errorf("invalid Name [%s]: must be a plain name without path separators", name)
+	}
+	return nil
+}
+
+// Image repositories, with an optional registry host[:port], and tags (as the docker reference grammar)
+var (
+	imageRepository = regexp.MustCompile(`^(?:[A-Za-z0-9.-]+(?::[0-9]+)?/)?[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	imageTag        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// The KDK image repository and tag must both be present and well formed, since ImageCoordinates
+// joins them as repository:tag
+func validateImage(repository, tag string) error {
+	if strings.TrimSpace(repository) == "" {
+		return errors.New("invalid AppConfig.ImageRepository: must not be empty")
+	}
+	if !imageRepository.MatchString(repository) {
+		return fmt.Errorf("invalid AppConfig.ImageRepository [%s]: must be a lowercase repository name without spaces or a tag", repository)
+	}
+	if strings.TrimSpace(tag) == "" {
+		return errors.New("invalid AppConfig.ImageTag: must not be empty")
+	}
+	if !imageTag.MatchString(tag) {
+		return fmt.Errorf("invalid AppConfig.ImageTag [%s]: must be letters, digits, '_', '.', or '-' without spaces", tag)
+	}
+	return nil
+}
+
+// A platform is os/arch[/variant], e.g. linux/amd64 or linux/arm64/v8
+func validatePlatform(platform string) error {
+	if platform == "" {
+		return nil
+	}
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("invalid Platform [%s]: must be os/arch[/variant]", platform)
+	}
+	for _, part := range parts {
+		if part == "" || strings.ToLower(part) != part || strings.ContainsAny(part, " \t") {
+			return fmt.Errorf("invalid Platform [%s]: must be lowercase os/arch[/variant]", platform)
+		}
+	}
+	return nil
+}
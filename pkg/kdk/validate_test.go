@@ -0,0 +1,100 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateImage(t *testing.T) {
+
+	for _, valid := range [][2]string{
+		{"ciscosso/kdk", "1.0.0"},
+		{"registry.example.com:5000/team/kdk", "latest"},
+		{"kdk", "v1.2_rc-1"},
+	} {
+		if err := validateImage(valid[0], valid[1]); err != nil {
+			t.Logf("validateImage rejected valid image [%s:%s]. %v", valid[0], valid[1], err)
+			t.FailNow()
+		}
+	}
+
+	if err := validateImage("", "1.0.0"); err == nil || !strings.Contains(err.Error(), "ImageRepository") {
+		t.Log("validateImage did not report an empty ImageRepository.", err)
+		t.FailNow()
+	}
+	if err := validateImage("ciscosso/kdk", ""); err == nil || !strings.Contains(err.Error(), "ImageTag") {
+		t.Log("validateImage did not report an empty ImageTag.", err)
+		t.FailNow()
+	}
+}
+
+func TestValidateImageWhitespace(t *testing.T) {
+
+	for _, invalid := range [][2]string{
+		{"   ", "1.0.0"},
+		{"ciscosso/kdk", "  "},
+		{"cisco sso/kdk", "1.0.0"},
+		{"ciscosso/kdk", "1.0 .0"},
+		{" ciscosso/kdk", "1.0.0"},
+		{"ciscosso/kdk", "1.0.0\n"},
+	} {
+		if err := validateImage(invalid[0], invalid[1]); err == nil {
+			t.Logf("validateImage accepted image with whitespace [%q:%q].", invalid[0], invalid[1])
+			t.FailNow()
+		}
+	}
+
+	if err := validateImage("ciscosso/kdk:1.0.0", "1.0.0"); err == nil {
+		t.Log("validateImage accepted a tag within the ImageRepository.")
+		t.FailNow()
+	}
+}
+
+func TestValidateLogDriver(t *testing.T) {
+
+	for _, valid := range []string{"", "json-file", "journald", "loki", "grafana/loki-docker-driver:latest"} {
+		if err := validateLogDriver(valid); err != nil {
+			t.Logf("validateLogDriver rejected valid log driver [%s]. %v", valid, err)
+			t.FailNow()
+		}
+	}
+	for _, invalid := range []string{" json-file", "json file", "loki\n"} {
+		if err := validateLogDriver(invalid); err == nil {
+			t.Logf("validateLogDriver accepted log driver with whitespace [%q]", invalid)
+			t.FailNow()
+		}
+	}
+}
+
+func TestValidateSidecarsNetwork(t *testing.T) {
+
+	sidecars := []SidecarSpec{{Name: "db", Image: "postgres:12"}}
+	for _, network := range []string{"", "bridge", "host", "none", "default"} {
+		if err := validateSidecars(network, sidecars); err == nil || !strings.Contains(err.Error(), "user-defined Network") {
+			t.Logf("validateSidecars accepted predefined network [%s]. %v", network, err)
+			t.FailNow()
+		}
+		if err := validateNetworkAliases(network, []string{"dev"}); err == nil {
+			t.Logf("validateNetworkAliases accepted predefined network [%s]", network)
+			t.FailNow()
+		}
+	}
+	if err := validateSidecars("kdk", sidecars); err != nil {
+		t.Log("validateSidecars rejected a user-defined network.", err)
+		t.FailNow()
+	}
+}
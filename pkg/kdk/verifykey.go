@@ -0,0 +1,113 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Confirm that ssh into the started KDK container works: the KDK public key is in the user's
+// authorized_keys, sshd StrictModes accepts the ownership and permissions of the home, ~/.ssh and
+// authorized_keys, and a test ssh authentication succeeds.  The error names the exact problem.
+func (c *KdkEnvConfig) VerifyAuthorizedKey() error {
+	home := c.ContainerHome()
+	sshDir := path.Join(home, ".ssh")
+	authorizedKeys := path.Join(sshDir, "authorized_keys")
+	for _, p := range []string{home, sshDir, authorizedKeys} {
+		if err := c.verifyOwnerMode(p); err != nil {
+			return err
+		}
+	}
+
+	publicKeyData, err := c.readPublicKey()
+	if err != nil {
+		return err
+	}
+	publicKey, _, _, _, err := gossh.ParseAuthorizedKey(publicKeyData)
+	if err != nil {
+		return fmt.Errorf("invalid KDK public key %s: %v", c.PublicKeyPath(), err)
+	}
+	out, exitCode, err := c.containerExec("", "cat", authorizedKeys)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("failed to read %s: %s", authorizedKeys, strings.TrimSpace(out))
+	}
+	if !hasAuthorizedKey([]byte(out), publicKey) {
+		return fmt.Errorf("KDK public key %s (%s) is missing from %s", c.PublicKeyPath(), gossh.FingerprintSHA256(publicKey), authorizedKeys)
+	}
+
+	// without a private key on disk there is nothing to authenticate with
+	if c.InMemory {
+		return nil
+	}
+	client, err := c.dialSSH()
+	if err != nil {
+		return fmt.Errorf("test ssh authentication as [%s] failed: %v", c.User(), err)
+	}
+	client.Close()
+	log.Debug("Verified KDK ssh authentication")
+	return nil
+}
+
+// sshd StrictModes requires p to be owned by the KDK user (or root) and not writable by group or others
+func (c *KdkEnvConfig) verifyOwnerMode(p string) error {
+	out, exitCode, err := c.containerExec("", "stat", "-c", "%a %U", p)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("%s is missing in the KDK container: %s", p, strings.TrimSpace(out))
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return fmt.Errorf("unexpected stat output for %s: %s", p, out)
+	}
+	mode, err := strconv.ParseUint(fields[0], 8, 32)
+	if err != nil {
+		return fmt.Errorf("unexpected stat output for %s: %s", p, out)
+	}
+	if owner := fields[1]; owner != c.User() && owner != "root" {
+		return fmt.Errorf("%s is owned by [%s], sshd requires [%s]", p, owner, c.User())
+	}
+	if mode&022 != 0 {
+		return fmt.Errorf("%s has mode %s, sshd requires it is not group or other writable", p, fields[0])
+	}
+	return nil
+}
+
+// Whether authorized_keys content holds key
+func hasAuthorizedKey(authorizedKeys []byte, key gossh.PublicKey) bool {
+	rest := authorizedKeys
+	for len(rest) > 0 {
+		parsed, _, _, next, err := gossh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return false
+		}
+		if bytes.Equal(parsed.Marshal(), key.Marshal()) {
+			return true
+		}
+		rest = next
+	}
+	return false
+}
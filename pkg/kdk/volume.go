@@ -0,0 +1,102 @@
+// Copyright © 2018 Cisco Systems, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdk
+
+import (
+	"io"
+	"os"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	log "github.com/sirupsen/logrus"
+)
+
+// Mount point of the volume in the throwaway backup container.  Backup tarballs hold the volume
+// contents under volume/.
+const volumeBackupTarget = "/volume"
+
+// Create (but do not start) a throwaway container of the KDK image with volume name mounted.
+// Docker copies to and from the volumes of stopped containers.
+func (c *KdkEnvConfig) createVolumeContainer(name string, readOnly bool) (string, error) {
+	resp, err := c.DockerClient.ContainerCreate(
+		c.Ctx,
+		&container.Config{Image: c.ImageCoordinates(), Cmd: []string{"true"}},
+		&container.HostConfig{Mounts: []mount.Mount{
+			{Type: mount.TypeVolume, Source: name, Target: volumeBackupTarget, ReadOnly: readOnly},
+		}},
+		nil,
+		"",
+	)
+	if err != nil {
+		return "", wrapImageError(err)
+	}
+	return resp.ID, nil
+}
+
+func (c *KdkEnvConfig) removeVolumeContainer(id string) {
+	if err := c.DockerClient.ContainerRemove(c.Ctx, id, types.ContainerRemoveOptions{Force: true}); err != nil {
+		log.WithField("error", err).Warnf("Failed to remove volume backup container [%s]", id[:12])
+	}
+}
+
+// Back up the contents of the named volume to the tarball dst
+func (c *KdkEnvConfig) BackupVolume(name, dst string) error {
+	if _, err := c.DockerClient.VolumeInspect(c.Ctx, name); err != nil {
+		return wrapDockerError(err)
+	}
+	id, err := c.createVolumeContainer(name, true)
+	if err != nil {
+		return err
+	}
+	defer c.removeVolumeContainer(id)
+
+	content, _, err := c.DockerClient.CopyFromContainer(c.Ctx, id, volumeBackupTarget)
+	if err != nil {
+		return wrapDockerError(err)
+	}
+	defer content.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := io.Copy(out, content); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Restore a tarball created by BackupVolume into the named volume, creating it if missing.
+// Files in the tarball replace those in the volume; other files are kept.
+func (c *KdkEnvConfig) RestoreVolume(name, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	id, err := c.createVolumeContainer(name, false)
+	if err != nil {
+		return err
+	}
+	defer c.removeVolumeContainer(id)
+
+	if err := c.DockerClient.CopyToContainer(c.Ctx, id, "/", in, types.CopyToContainerOptions{}); err != nil {
+		return wrapDockerError(err)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -124,41 +125,97 @@ func StopMirror(configDir string) error {
 	return nil
 }
 
-// Get keybase mounts
+// A keybase host path and its target within the KDK container
+type Mount struct {
+	Source string
+	Target string
+}
+
+// Detect the keybase filesystem root
 // Linux & OSX: Detect /keybase
 // Windows10: Detect k: and /k
-func GetMounts(configRootDir string) (source string, target string, err error) {
-
+func detectRoot() (string, error) {
 	keybaseRoots := []string{"/keybase", "/Volumes/keybase", "k:", "/k"}
 	keybaseTestSubdir := "/private"
 	for _, keybaseRoot := range keybaseRoots {
 		if absPath, err := filepath.Abs(filepath.Join(keybaseRoot, keybaseTestSubdir)); err == nil {
 			if path, err := filepath.EvalSymlinks(absPath); err == nil {
-				source := filepath.Dir(path)
-				target := "/keybase"
-
-				log.Infof("Detected keybase filesystem at: %v", source)
-
-				prmpt := prompt.Prompt{
-					Text:     "Mount your keybase directory within KDK? [y/n] ",
-					Loop:     true,
-					Validate: prompt.ValidateYorN,
-				}
-				if result, err := prmpt.Run(); err == nil && result == "y" {
-					log.Info("Adding /keybase mount to configuration")
-					if runtime.GOOS == "windows" {
-						source = filepath.Join(configRootDir, "keybase")
-						if _, err := os.Stat(source); os.IsNotExist(err) {
-							if err := os.Mkdir(source, 0700); err != nil {
-								log.WithField("error", err).Fatalf("Failed to create KDK keybase mirror directory [%s]", source)
-								return "", "", err
-							}
-						}
-					}
-					return source, target, nil
-				}
+				return filepath.Dir(path), nil
+			}
+		}
+	}
+	return "", errors.New("Failed to detect potential keybase filesystem mounts")
+}
+
+// Get mounts for selected keybase subpaths (e.g. team/<name>), rather than the whole keybase filesystem.
+// Each subpath must exist, and is mounted at /keybase/<subpath>.
+func GetPathMounts(configRootDir string, subpaths []string) ([]Mount, error) {
+	root, err := detectRoot()
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Detected keybase filesystem at: %v", root)
+
+	var mounts []Mount
+	for _, subpath := range subpaths {
+		clean := path.Clean("/" + filepath.ToSlash(subpath))
+		if clean == "/" {
+			return nil, fmt.Errorf("invalid keybase path [%s]", subpath)
+		}
+		source := filepath.Join(root, filepath.FromSlash(clean))
+		if _, err := os.Stat(source); err != nil {
+			return nil, fmt.Errorf("keybase path [%s] does not exist", source)
+		}
+		if runtime.GOOS == "windows" {
+			if err := createMirrorDir(filepath.Join(configRootDir, "keybase")); err != nil {
+				return nil, err
 			}
+			source = filepath.Join(configRootDir, "keybase", filepath.FromSlash(clean))
 		}
+		log.Infof("Adding keybase %s mount to configuration", clean)
+		mounts = append(mounts, Mount{Source: source, Target: path.Join("/keybase", clean)})
 	}
-	return "", "", errors.New("Failed to detect potential keybase filesystem mounts")
+	return mounts, nil
+}
+
+// Get keybase mounts
+// Linux & OSX: Detect /keybase
+// Windows10: Detect k: and /k
+func GetMounts(configRootDir string) (source string, target string, err error) {
+
+	source, err = detectRoot()
+	if err != nil {
+		return "", "", err
+	}
+	target = "/keybase"
+
+	log.Infof("Detected keybase filesystem at: %v", source)
+
+	prmpt := prompt.Prompt{
+		Text:     "Mount your keybase directory within KDK? [y/n] ",
+		Loop:     true,
+		Validate: prompt.ValidateYorN,
+	}
+	if result, err := prmpt.Run(); err == nil && result == "y" {
+		log.Info("Adding /keybase mount to configuration")
+		if runtime.GOOS == "windows" {
+			source = filepath.Join(configRootDir, "keybase")
+			if err := createMirrorDir(source); err != nil {
+				return "", "", err
+			}
+		}
+		return source, target, nil
+	}
+	return "", "", errors.New("Keybase mount declined")
+}
+
+// Create the KDK keybase mirror directory [windows only]
+func createMirrorDir(mirrorDir string) error {
+	if _, err := os.Stat(mirrorDir); os.IsNotExist(err) {
+		if err := os.Mkdir(mirrorDir, 0700); err != nil {
+			log.WithField("error", err).Errorf("Failed to create KDK keybase mirror directory [%s]", mirrorDir)
+			return err
+		}
+	}
+	return nil
 }
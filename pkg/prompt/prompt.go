@@ -5,6 +5,7 @@ sp := Prompt{
 	Text: "Mount your /keybase directory within KDK? [y/n] ",
 	Loop: true,
 	Validate: ValidateYorN,
+	Default: "y", // optional: used when the user just presses Enter, displayed as "[y] "
 }
 
 result, err := sp.Run()
@@ -17,12 +18,14 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Prompt struct {
 	Text     string
 	Loop     bool
 	Validate func(string) error
+	Default  string
 }
 
 func (sp *Prompt) Run() (string, error) {
@@ -30,13 +33,21 @@ func (sp *Prompt) Run() (string, error) {
 	scanner := bufio.NewScanner(os.Stdin)
 
 	for {
-		// Print the description
+		// Print the description, and the default if one exists
 		fmt.Print(sp.Text)
+		if sp.Default != "" {
+			fmt.Printf("[%s] ", sp.Default)
+		}
 
 		// Block and read the input
 		scanner.Scan()
 		text := scanner.Text()
 
+		// Use the default when the user just presses Enter
+		if text == "" {
+			text = sp.Default
+		}
+
 		// If no validation function exists, return the text immediately
 		if sp.Validate == nil {
 			return text, nil
@@ -82,3 +93,70 @@ func ValidateIntOrEmptyString(input string) error {
 	}
 	return errors.New("Input must be an integer or empty string")
 }
+
+// Select is a prompt to pick one of Options, by number or by a fuzzy match of its text
+type Select struct {
+	Text    string
+	Options []string
+}
+
+func (s *Select) Run() (string, error) {
+	if len(s.Options) == 0 {
+		return "", errors.New("Nothing to select")
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	options := s.Options
+
+	for {
+		for i, option := range options {
+			fmt.Printf("  %d) %s\n", i+1, option)
+		}
+		fmt.Print(s.Text)
+
+		if !scanner.Scan() {
+			return "", errors.New("Failed to capture valid input")
+		}
+		text := strings.TrimSpace(scanner.Text())
+
+		// A number selects from the options listed
+		if n, err := strconv.Atoi(text); err == nil {
+			if n >= 1 && n <= len(options) {
+				return options[n-1], nil
+			}
+			fmt.Printf("Input must be between 1 and %d\n", len(options))
+			continue
+		}
+
+		// Otherwise narrow the options to the fuzzy matches
+		var matches []string
+		for _, option := range s.Options {
+			if option == text {
+				return option, nil
+			}
+			if FuzzyMatch(text, option) {
+				matches = append(matches, option)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			fmt.Printf("No match for [%s]\n", text)
+			options = s.Options
+		case 1:
+			return matches[0], nil
+		default:
+			options = matches
+		}
+	}
+}
+
+// FuzzyMatch reports whether the characters of pattern appear in order in text, ignoring case
+func FuzzyMatch(pattern, text string) bool {
+	runes := []rune(strings.ToLower(pattern))
+	i := 0
+	for _, r := range strings.ToLower(text) {
+		if i < len(runes) && runes[i] == r {
+			i++
+		}
+	}
+	return i == len(runes)
+}
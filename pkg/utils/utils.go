@@ -15,6 +15,7 @@
 package utils
 
 import (
+	"errors"
 	"net"
 	"reflect"
 )
@@ -37,15 +38,38 @@ func Contains(input interface{}, target interface{}) bool {
 
 // Ask kernel for a free port
 func GetPort() int {
-	if out, err := net.ResolveTCPAddr("tcp", "localhost:0"); err != nil {
+	port, listen, err := ReservePort()
+	if err != nil {
 		return 0
-	} else {
-		if listen, err := net.ListenTCP("tcp", out); err != nil {
-			defer listen.Close()
-			return 0
-		} else {
-			defer listen.Close()
-			return listen.Addr().(*net.TCPAddr).Port
+	}
+	listen.Close()
+	return port
+}
+
+// Ask kernel for a free port, and hold it with the returned listener.  The port is guaranteed
+// free until the listener is closed, which the caller should do immediately before binding.
+func ReservePort() (int, net.Listener, error) {
+	out, err := net.ResolveTCPAddr("tcp", "localhost:0")
+	if err != nil {
+		return 0, nil, err
+	}
+	listen, err := net.ListenTCP("tcp", out)
+	if err != nil {
+		return 0, nil, err
+	}
+	return listen.Addr().(*net.TCPAddr).Port, listen, nil
+}
+
+// Call bind with port.  While bind fails because the port was taken in the meantime, retry
+// up to attempts times with a fresh port.  Returns the port that was successfully bound.
+func BindWithRetry(port int, attempts int, bind func(int) error, isPortInUse func(error) bool) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := bind(port)
+		if err == nil || !isPortInUse(err) || attempt >= attempts {
+			return port, err
+		}
+		if port = GetPort(); port == 0 {
+			return 0, errors.New("failed to find a free port")
 		}
 	}
 }
@@ -15,6 +15,9 @@
 package utils
 
 import (
+	"net"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -85,3 +88,44 @@ func TestSliceContainsCustom(t *testing.T) {
 	}
 
 }
+
+func TestBindWithRetryPortTaken(t *testing.T) {
+
+	// Select a port, then let something else take it before we bind
+	port, taken, err := ReservePort()
+	if err != nil {
+		t.Log("ReservePort failed to reserve a port.", err)
+		t.FailNow()
+	}
+	defer taken.Close()
+
+	var bound net.Listener
+	bind := func(port int) error {
+		listen, err := net.Listen("tcp", "localhost:"+strconv.Itoa(port))
+		if err != nil {
+			return err
+		}
+		bound = listen
+		return nil
+	}
+	isPortInUse := func(err error) bool {
+		return strings.Contains(err.Error(), "address already in use")
+	}
+
+	boundPort, err := BindWithRetry(port, 3, bind, isPortInUse)
+	if err != nil {
+		t.Log("BindWithRetry failed to bind a fresh port.", err)
+		t.FailNow()
+	}
+	defer bound.Close()
+	if boundPort == port {
+		t.Log("BindWithRetry reports binding the taken port.")
+		t.FailNow()
+	}
+
+	// A single attempt surfaces the bind error
+	if _, err := BindWithRetry(port, 1, bind, isPortInUse); err == nil {
+		t.Log("BindWithRetry bound a taken port.")
+		t.FailNow()
+	}
+}